@@ -7,6 +7,15 @@ import (
 
 var (
 	ErrTooLessDone = errors.New("async: too less tasks/actions to completed without error")
+	// ErrTooManyFailures is returned when the failed tasks/actions reach the ErrorPolicy
+	ErrTooManyFailures = errors.New("async: too many tasks/actions failed")
+
+	// ErrQuorumReached is the cancellation cause of the tasks/actions that are still running when WaitN has got enough results
+	ErrQuorumReached = errors.New("async: quorum reached")
+	// ErrSiblingFailed is the cancellation cause of the tasks/actions that are still running when the others have failed too many to wait
+	ErrSiblingFailed = errors.New("async: sibling task/action failed")
+	// ErrPanic is returned when a task/action panics, it is wrapped with the recovered value and the stack
+	ErrPanic = errors.New("async: task/action panicked")
 )
 
 // Task a task with result T
@@ -14,9 +23,7 @@ type Task[T any] func(ctx context.Context) (T, error)
 
 // New create a task waiter
 func New[T any](tasks ...Task[T]) Waiter[T] {
-	return &waiter[T]{
-		tasks: tasks,
-	}
+	return newWaiter(tasks...)
 }
 
 // Action a task without result
@@ -24,7 +31,13 @@ type Action func(ctx context.Context) error
 
 // NewA create an action awaiter
 func NewA(actions ...Action) Awaiter {
-	return &awaiter{
-		actions: actions,
+	a := &awaiter{
+		w: newWaiter[struct{}](),
 	}
+
+	for _, action := range actions {
+		a.Add(action)
+	}
+
+	return a
 }
@@ -0,0 +1,184 @@
+package asynctest
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"math"
+	"math/rand"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/yaitoo/async"
+)
+
+// ErrInjected is returned or panicked by the failures that are injected by Chaos
+var ErrInjected = errors.New("asynctest: injected failure")
+
+// Latency a distribution of the latency that is injected by Chaos
+type Latency func(r *rand.Rand) time.Duration
+
+// Fixed always d
+func Fixed(d time.Duration) Latency {
+	return func(r *rand.Rand) time.Duration {
+		return d
+	}
+}
+
+// Uniform uniformly distributed in [min, max)
+func Uniform(min, max time.Duration) Latency {
+	return func(r *rand.Rand) time.Duration {
+		if max <= min {
+			return min
+		}
+		return min + time.Duration(r.Int63n(int64(max-min)))
+	}
+}
+
+// Normal normally distributed with mean and stddev, the negative ones are 0
+func Normal(mean, stddev time.Duration) Latency {
+	return func(r *rand.Rand) time.Duration {
+		return time.Duration(math.Max(0, r.NormFloat64()*float64(stddev)+float64(mean)))
+	}
+}
+
+// Exponential exponentially distributed with mean, e.g. the long tail of a slow dependency
+func Exponential(mean time.Duration) Latency {
+	return func(r *rand.Rand) time.Duration {
+		return time.Duration(r.ExpFloat64() * float64(mean))
+	}
+}
+
+// ChaosConfig the faults that are injected by Chaos, the rates are probabilities in [0, 1]
+type ChaosConfig struct {
+	// Latency delays each call unless its context is canceled, no latency if it is nil
+	Latency Latency
+	// ErrorRate fails calls with Err without calling the task/action
+	ErrorRate float64
+	// Err is ErrInjected by default
+	Err error
+	// PanicRate panics calls with ErrInjected, the APIs of async recover it as an error that wraps async.ErrPanic
+	PanicRate float64
+	// HangRate hangs calls ignoring their context until Release is called, then they return ErrInjected
+	HangRate float64
+}
+
+// Chaos injects faults into tasks/actions by a seeded random source, see Inject and InjectA. The faults of a call are
+// derived from the seed and its TaskInfo (Index, Name and Attempt) if it is started by Waiter/Awaiter/Scheduler, so
+// they are reproduced exactly by the same seed regardless of scheduling. Otherwise they are drawn from a sequence of the
+// seed in calling order.
+type Chaos struct {
+	seed int64
+	cfg  ChaosConfig
+
+	mu       sync.Mutex
+	rnd      *rand.Rand
+	released chan struct{}
+	once     sync.Once
+}
+
+// NewChaos create a Chaos with seed and cfg
+func NewChaos(seed int64, cfg ChaosConfig) *Chaos {
+	if cfg.Err == nil {
+		cfg.Err = ErrInjected
+	}
+
+	return &Chaos{
+		seed:     seed,
+		cfg:      cfg,
+		rnd:      rand.New(rand.NewSource(seed)),
+		released: make(chan struct{}),
+	}
+}
+
+// Release unblock the calls that hang, and the ones that hang later return immediately
+func (c *Chaos) Release() {
+	c.once.Do(func() {
+		close(c.released)
+	})
+}
+
+// fault the faults of a call
+type fault struct {
+	hang    bool
+	panic   bool
+	fail    bool
+	latency time.Duration
+}
+
+func (c *Chaos) draw(ctx context.Context) fault {
+	var r *rand.Rand
+	if info, ok := async.InfoFrom(ctx); ok {
+		h := fnv.New64a()
+		// WaiterID is not stable across processes
+		h.Write([]byte(strconv.Itoa(info.Index) + "/" + info.Name + "/" + strconv.Itoa(info.Attempt)))
+		r = rand.New(rand.NewSource(c.seed ^ int64(h.Sum64())))
+	} else {
+		c.mu.Lock()
+		r = rand.New(rand.NewSource(c.rnd.Int63()))
+		c.mu.Unlock()
+	}
+
+	// all values are drawn in the same order, so changing a rate doesn't shift the others
+	f := fault{
+		hang:  r.Float64() < c.cfg.HangRate,
+		panic: r.Float64() < c.cfg.PanicRate,
+		fail:  r.Float64() < c.cfg.ErrorRate,
+	}
+	if c.cfg.Latency != nil {
+		f.latency = c.cfg.Latency(r)
+	}
+	return f
+}
+
+// inject apply the faults of a call before it calls fn
+func (c *Chaos) inject(ctx context.Context) error {
+	f := c.draw(ctx)
+
+	if f.hang {
+		<-c.released
+		return ErrInjected
+	}
+
+	if f.panic {
+		panic(ErrInjected)
+	}
+
+	if f.latency > 0 {
+		timer := async.ClockFrom(ctx).NewTimer(f.latency)
+		select {
+		case <-timer.C():
+		case <-ctx.Done():
+			timer.Stop()
+			return context.Cause(ctx)
+		}
+	}
+
+	if f.fail {
+		return c.cfg.Err
+	}
+
+	return nil
+}
+
+// Inject inject the faults of c into task
+func Inject[T any](task async.Task[T], c *Chaos) async.Task[T] {
+	return func(ctx context.Context) (T, error) {
+		if err := c.inject(ctx); err != nil {
+			var t T
+			return t, err
+		}
+		return task(ctx)
+	}
+}
+
+// InjectA inject the faults of c into action
+func InjectA(action async.Action, c *Chaos) async.Action {
+	return func(ctx context.Context) error {
+		if err := c.inject(ctx); err != nil {
+			return err
+		}
+		return action(ctx)
+	}
+}
@@ -0,0 +1,207 @@
+package asynctest
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yaitoo/async"
+)
+
+func TestChaos(t *testing.T) {
+
+	ok := func(ctx context.Context) (int, error) {
+		info, _ := async.InfoFrom(ctx)
+		return info.Index, nil
+	}
+
+	run := func(seed int64, cfg ChaosConfig) []error {
+		c := NewChaos(seed, cfg)
+
+		w := async.New[int]()
+		for i := 0; i < 100; i++ {
+			w.Add(Inject(ok, c))
+		}
+
+		// errors are collected in completion order, so map them back by the results
+		failed := make([]error, 100)
+		for i := range failed {
+			failed[i] = ErrInjected
+		}
+		result, _, _ := w.Wait(context.Background())
+		for _, i := range result {
+			failed[i] = nil
+		}
+		return failed
+	}
+
+	t.Run("same_seed_should_reproduce_faults", func(t *testing.T) {
+		cfg := ChaosConfig{ErrorRate: 0.3}
+
+		first := run(42, cfg)
+		require.Equal(t, first, run(42, cfg))
+		require.NotEqual(t, first, run(7, cfg))
+
+		var n int
+		for _, err := range first {
+			if err != nil {
+				n++
+			}
+		}
+		require.InDelta(t, 30, n, 15)
+	})
+
+	t.Run("sequence_should_be_reproduced_without_task_info", func(t *testing.T) {
+		draw := func() []error {
+			task := Inject(ok, NewChaos(42, ChaosConfig{ErrorRate: 0.5, Err: context.Canceled}))
+
+			var errs []error
+			for i := 0; i < 20; i++ {
+				_, err := task(context.Background())
+				errs = append(errs, err)
+			}
+			return errs
+		}
+
+		require.Equal(t, draw(), draw())
+	})
+
+	t.Run("latency_should_use_clock", func(t *testing.T) {
+		c := NewClock(time.Now())
+		ctx := c.Context(context.Background())
+
+		action := InjectA(func(ctx context.Context) error {
+			return nil
+		}, NewChaos(1, ChaosConfig{Latency: Fixed(time.Minute)}))
+
+		errs := make(chan error, 1)
+		go func() {
+			errs <- action(ctx)
+		}()
+
+		require.NoError(t, c.BlockUntil(ctx, 1))
+		c.Advance(time.Minute)
+		require.NoError(t, <-errs)
+
+		// latency respects ctx
+		cctx, cancel := context.WithCancelCause(ctx)
+		cancel(async.ErrQuorumReached)
+		require.ErrorIs(t, action(cctx), async.ErrQuorumReached)
+	})
+
+	t.Run("latency_distributions_should_work", func(t *testing.T) {
+		r := rand.New(rand.NewSource(1))
+
+		for i := 0; i < 100; i++ {
+			d := Uniform(time.Second, 2*time.Second)(r)
+			require.GreaterOrEqual(t, d, time.Second)
+			require.Less(t, d, 2*time.Second)
+
+			require.GreaterOrEqual(t, Normal(time.Millisecond, time.Second)(r), time.Duration(0))
+			require.GreaterOrEqual(t, Exponential(time.Second)(r), time.Duration(0))
+		}
+
+		require.Equal(t, time.Second, Fixed(time.Second)(r))
+		require.Equal(t, time.Second, Uniform(time.Second, time.Second)(r))
+	})
+
+	t.Run("panic_should_work", func(t *testing.T) {
+		task := Inject(ok, NewChaos(1, ChaosConfig{PanicRate: 1}))
+
+		require.PanicsWithValue(t, ErrInjected, func() {
+			_, _ = task(context.Background())
+		})
+	})
+
+	t.Run("panic_should_be_returned_by_waiter", func(t *testing.T) {
+		c := NewChaos(1, ChaosConfig{PanicRate: 1})
+
+		_, taskErrs, err := async.New[int](Inject(ok, c)).Wait(context.Background())
+		require.ErrorIs(t, err, async.ErrTooLessDone)
+		require.Len(t, taskErrs, 1)
+		require.ErrorIs(t, taskErrs[0], async.ErrPanic)
+		require.ErrorIs(t, taskErrs[0], ErrInjected)
+
+		errs, err := async.NewA(InjectA(func(ctx context.Context) error {
+			return nil
+		}, c)).Wait(context.Background())
+		require.ErrorIs(t, err, async.ErrTooLessDone)
+		require.Len(t, errs, 1)
+		require.ErrorIs(t, errs[0], async.ErrPanic)
+	})
+
+	t.Run("panic_should_be_recovered", func(t *testing.T) {
+		c := NewChaos(1, ChaosConfig{PanicRate: 1})
+		ctx := context.Background()
+
+		report, err := async.NewDAG[int]().Add("a", Inject(ok, c)).Run(ctx)
+		require.ErrorIs(t, err, async.ErrTooLessDone)
+		a, _ := report.Node("a")
+		require.ErrorIs(t, a.Error, async.ErrPanic)
+
+		_, _, err = async.NewGroup().Do(ctx, "key", func(ctx context.Context) (any, error) {
+			return Inject(ok, c)(ctx)
+		})
+		require.ErrorIs(t, err, async.ErrPanic)
+
+		var errs []error
+		s := async.NewScheduler()
+		s.WithOptions(async.WithObserver(async.Observer{
+			OnDone: func(info async.TaskInfo, err error, elapsed time.Duration) {
+				errs = append(errs, err)
+			},
+		}))
+		s.After(ctx, 0, InjectA(func(ctx context.Context) error {
+			return nil
+		}, c))
+		s.Wait()
+		require.Len(t, errs, 1)
+		require.ErrorIs(t, errs[0], async.ErrPanic)
+
+		in := make(chan int, 1)
+		in <- 1
+		close(in)
+		for r := range async.OrderedMap(ctx, in, func(ctx context.Context, i int) (int, error) {
+			return Inject(ok, c)(ctx)
+		}, 1) {
+			require.ErrorIs(t, r.Error, async.ErrPanic)
+		}
+
+		p := async.NewPipeline(ctx)
+		in = make(chan int, 1)
+		in <- 1
+		close(in)
+		for range async.Pipe(p, in, async.Stage[int, int]{
+			Fn: func(ctx context.Context, i int) (int, error) {
+				return Inject(ok, c)(ctx)
+			},
+		}) {
+		}
+		require.ErrorIs(t, p.Wait(), async.ErrPanic)
+	})
+
+	t.Run("hang_should_ignore_context", func(t *testing.T) {
+		c := NewChaos(1, ChaosConfig{HangRate: 1})
+		task := Inject(ok, c)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		errs := make(chan error, 1)
+		go func() {
+			_, err := task(ctx)
+			errs <- err
+		}()
+
+		select {
+		case <-errs:
+			require.Fail(t, "hung task should ignore context")
+		case <-time.After(10 * time.Millisecond):
+		}
+
+		c.Release()
+		require.ErrorIs(t, <-errs, ErrInjected)
+	})
+}
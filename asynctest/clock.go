@@ -0,0 +1,132 @@
+// Package asynctest provides a fake clock and scripted tasks to test code that is built on async deterministically,
+// without sleeping.
+package asynctest
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/yaitoo/async"
+)
+
+// Clock a fake async.Clock whose time only moves when Advance is called. So the timeouts, retries, rate limits and
+// schedules that use it fire deterministically, see Context.
+type Clock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*timer
+	// added is closed and replaced once a timer is added, to wake up BlockUntil
+	added chan struct{}
+}
+
+// NewClock create a Clock at now
+func NewClock(now time.Time) *Clock {
+	return &Clock{
+		now:   now,
+		added: make(chan struct{}),
+	}
+}
+
+// Context get a copy of ctx that carries c, see async.ContextWithClock
+func (c *Clock) Context(ctx context.Context) context.Context {
+	return async.ContextWithClock(ctx, c)
+}
+
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+func (c *Clock) NewTimer(d time.Duration) async.Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t := &timer{
+		c:  c,
+		at: c.now.Add(d),
+		ch: make(chan time.Time, 1),
+	}
+
+	if d <= 0 {
+		t.ch <- c.now
+		return t
+	}
+
+	c.timers = append(c.timers, t)
+	close(c.added)
+	c.added = make(chan struct{})
+	return t
+}
+
+// Advance move the time forward by d, and fire the timers that are due in the order of their time
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+
+	sort.SliceStable(c.timers, func(i, j int) bool {
+		return c.timers[i].at.Before(c.timers[j].at)
+	})
+
+	var i int
+	for ; i < len(c.timers) && !c.timers[i].at.After(c.now); i++ {
+		c.timers[i].ch <- c.timers[i].at
+	}
+	c.timers = append(c.timers[:0], c.timers[i:]...)
+}
+
+// Timers get the number of timers that are waiting to fire
+func (c *Clock) Timers() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.timers)
+}
+
+// BlockUntil wait until at least n timers are waiting to fire, e.g. the goroutines under test are sleeping. The cause of
+// ctx is returned if it is canceled before that.
+func (c *Clock) BlockUntil(ctx context.Context, n int) error {
+	for {
+		c.mu.Lock()
+		if len(c.timers) >= n {
+			c.mu.Unlock()
+			return nil
+		}
+		added := c.added
+		c.mu.Unlock()
+
+		select {
+		case <-added:
+		case <-ctx.Done():
+			return context.Cause(ctx)
+		}
+	}
+}
+
+type timer struct {
+	c  *Clock
+	at time.Time
+	ch chan time.Time
+}
+
+func (t *timer) C() <-chan time.Time {
+	return t.ch
+}
+
+func (t *timer) Stop() bool {
+	t.c.mu.Lock()
+	defer t.c.mu.Unlock()
+
+	for i, it := range t.c.timers {
+		if it == t {
+			t.c.timers = append(t.c.timers[:i], t.c.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
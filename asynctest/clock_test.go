@@ -0,0 +1,144 @@
+package asynctest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yaitoo/async"
+)
+
+func TestClock(t *testing.T) {
+
+	start := time.Date(2024, 3, 18, 0, 0, 0, 0, time.UTC)
+
+	t.Run("timers_should_fire_by_advance", func(t *testing.T) {
+		c := NewClock(start)
+
+		t1 := c.NewTimer(2 * time.Second)
+		t2 := c.NewTimer(time.Second)
+		t3 := c.NewTimer(3 * time.Second)
+		require.Equal(t, 3, c.Timers())
+
+		c.Advance(time.Second)
+		require.Equal(t, start.Add(time.Second), <-t2.C())
+		require.Equal(t, start.Add(time.Second), c.Now())
+
+		require.True(t, t3.Stop())
+		require.False(t, t3.Stop())
+
+		c.Advance(5 * time.Second)
+		require.Equal(t, start.Add(2*time.Second), <-t1.C())
+		require.False(t, t1.Stop())
+		require.Zero(t, c.Timers())
+
+		select {
+		case <-t3.C():
+			require.Fail(t, "stopped timer should not fire")
+		default:
+		}
+
+		// expired timer fires immediately
+		require.Equal(t, c.Now(), <-c.NewTimer(0).C())
+	})
+
+	t.Run("block_until_should_work", func(t *testing.T) {
+		c := NewClock(start)
+
+		go func() {
+			c.NewTimer(time.Second)
+		}()
+		require.NoError(t, c.BlockUntil(context.Background(), 1))
+
+		ctx, cancel := context.WithCancelCause(context.Background())
+		cancel(async.ErrSiblingFailed)
+		require.ErrorIs(t, c.BlockUntil(ctx, 2), async.ErrSiblingFailed)
+	})
+
+	t.Run("timeout_should_work", func(t *testing.T) {
+		c := NewClock(start)
+		ctx := c.Context(context.Background())
+
+		task := async.Timeout(func(ctx context.Context) (int, error) {
+			<-ctx.Done()
+			return 0, context.Cause(ctx)
+		}, time.Hour)
+
+		errs := make(chan error, 1)
+		go func() {
+			_, err := task(ctx)
+			errs <- err
+		}()
+
+		require.NoError(t, c.BlockUntil(ctx, 1))
+		c.Advance(time.Hour)
+		require.ErrorIs(t, <-errs, context.DeadlineExceeded)
+	})
+
+	t.Run("retry_should_work", func(t *testing.T) {
+		c := NewClock(start)
+		ctx := c.Context(context.Background())
+
+		s := NewScript[int]()
+		s.Fail(errors.New("first"))
+		s.Succeed(1)
+
+		results := make(chan int, 1)
+		go func() {
+			v, err := async.Retry(s.Task(), 2, time.Minute)(ctx)
+			require.NoError(t, err)
+			results <- v
+		}()
+
+		require.NoError(t, c.BlockUntil(ctx, 1))
+		require.Equal(t, 1, s.Calls())
+
+		c.Advance(time.Minute)
+		require.Equal(t, 1, <-results)
+		require.Equal(t, 2, s.Calls())
+	})
+
+	t.Run("rate_limit_should_work", func(t *testing.T) {
+		c := NewClock(start)
+		ctx := c.Context(context.Background())
+
+		l := async.NewRateLimiter(1, 1)
+		require.NoError(t, l.Wait(ctx))
+
+		errs := make(chan error, 1)
+		go func() {
+			errs <- l.Wait(ctx)
+		}()
+
+		require.NoError(t, c.BlockUntil(ctx, 1))
+		c.Advance(time.Second)
+		require.NoError(t, <-errs)
+	})
+
+	t.Run("scheduler_should_work", func(t *testing.T) {
+		c := NewClock(start)
+		ctx, cancel := context.WithCancel(c.Context(context.Background()))
+
+		runs := make(chan async.TaskInfo)
+		s := async.NewScheduler()
+		s.Every(ctx, time.Minute, func(ctx context.Context) error {
+			info, _ := async.InfoFrom(ctx)
+			runs <- info
+			return nil
+		}, async.WithOverlap(async.OverlapAllow)) // the next run isn't skipped if the previous one is not returned yet
+
+		for i := 1; i <= 3; i++ {
+			require.NoError(t, c.BlockUntil(ctx, 1))
+			c.Advance(time.Minute)
+
+			info := <-runs
+			require.Equal(t, i, info.Attempt)
+			require.Equal(t, start.Add(time.Duration(i)*time.Minute), c.Now())
+		}
+
+		cancel()
+		s.Wait()
+	})
+}
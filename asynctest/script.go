@@ -0,0 +1,128 @@
+package asynctest
+
+import (
+	"context"
+	"sync"
+
+	"github.com/yaitoo/async"
+)
+
+type outcome[T any] struct {
+	data T
+	err  error
+}
+
+// Script a task/action whose outcomes are scripted by test, so it is completed on demand. Each call of its task/action
+// waits for the next outcome that is given by Succeed or Fail, or for its context to be canceled.
+type Script[T any] struct {
+	mu       sync.Mutex
+	outcomes []outcome[T]
+	// ready is closed and replaced once an outcome is given
+	ready    chan struct{}
+	calls    int
+	started  chan struct{}
+	canceled chan struct{}
+	cause    error
+}
+
+// NewScript create a Script without outcomes
+func NewScript[T any]() *Script[T] {
+	return &Script[T]{
+		ready:    make(chan struct{}),
+		started:  make(chan struct{}),
+		canceled: make(chan struct{}),
+	}
+}
+
+// Task get the task of s
+func (s *Script[T]) Task() async.Task[T] {
+	return s.call
+}
+
+// Action get the action of s, the data of outcomes are ignored
+func (s *Script[T]) Action() async.Action {
+	return func(ctx context.Context) error {
+		_, err := s.call(ctx)
+		return err
+	}
+}
+
+// Succeed complete the next call with v
+func (s *Script[T]) Succeed(v T) {
+	s.push(outcome[T]{data: v})
+}
+
+// Fail complete the next call with err
+func (s *Script[T]) Fail(err error) {
+	s.push(outcome[T]{err: err})
+}
+
+// Started get the channel that is closed once the task/action is called
+func (s *Script[T]) Started() <-chan struct{} {
+	return s.started
+}
+
+// Canceled get the channel that is closed once a call is canceled by its context for the first time, see Cause
+func (s *Script[T]) Canceled() <-chan struct{} {
+	return s.canceled
+}
+
+// Cause get the cause of context that the call is canceled with, see Canceled
+func (s *Script[T]) Cause() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.cause
+}
+
+// Calls get the number of times that the task/action is called
+func (s *Script[T]) Calls() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.calls
+}
+
+func (s *Script[T]) push(o outcome[T]) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.outcomes = append(s.outcomes, o)
+	close(s.ready)
+	s.ready = make(chan struct{})
+}
+
+func (s *Script[T]) call(ctx context.Context) (T, error) {
+	s.mu.Lock()
+	s.calls++
+	if s.calls == 1 {
+		close(s.started)
+	}
+	s.mu.Unlock()
+
+	for {
+		s.mu.Lock()
+		if len(s.outcomes) > 0 {
+			o := s.outcomes[0]
+			s.outcomes = s.outcomes[1:]
+			s.mu.Unlock()
+			return o.data, o.err
+		}
+		ready := s.ready
+		s.mu.Unlock()
+
+		select {
+		case <-ready:
+		case <-ctx.Done():
+			s.mu.Lock()
+			if s.cause == nil {
+				s.cause = context.Cause(ctx)
+				close(s.canceled)
+			}
+			s.mu.Unlock()
+
+			var t T
+			return t, context.Cause(ctx)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package asynctest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yaitoo/async"
+)
+
+func TestScript(t *testing.T) {
+
+	wantedErr := errors.New("wanted")
+
+	t.Run("wait_should_work", func(t *testing.T) {
+		s1, s2 := NewScript[int](), NewScript[int]()
+		s1.Succeed(1)
+		s2.Fail(wantedErr)
+
+		result, errs, err := async.New[int](s1.Task(), s2.Task()).Wait(context.Background())
+		require.ErrorIs(t, err, async.ErrTooLessDone)
+		require.Equal(t, []int{1}, result)
+		require.Equal(t, []error{wantedErr}, errs)
+	})
+
+	t.Run("wait_any_should_cancel_others", func(t *testing.T) {
+		s1, s2, s3 := NewScript[int](), NewScript[int](), NewScript[int]()
+		s2.Succeed(2)
+
+		result, errs, err := async.New[int](s1.Task(), s2.Task(), s3.Task()).WaitAny(context.Background())
+		require.NoError(t, err)
+		require.Empty(t, errs)
+		require.Equal(t, 2, result)
+
+		for _, s := range []*Script[int]{s1, s3} {
+			<-s.Canceled()
+			require.ErrorIs(t, s.Cause(), async.ErrQuorumReached)
+		}
+	})
+
+	t.Run("wait_n_should_give_up_once_n_is_impossible", func(t *testing.T) {
+		s1, s2, s3 := NewScript[int](), NewScript[int](), NewScript[int]()
+		s1.Fail(wantedErr)
+		s2.Fail(wantedErr)
+
+		_, errs, err := async.New[int](s1.Task(), s2.Task(), s3.Task()).WaitN(context.Background(), 2)
+		require.ErrorIs(t, err, async.ErrTooLessDone)
+		require.Len(t, errs, 2)
+
+		<-s3.Canceled()
+		require.ErrorIs(t, s3.Cause(), async.ErrSiblingFailed)
+	})
+
+	t.Run("completed_on_demand_should_work", func(t *testing.T) {
+		s1, s2 := NewScript[struct{}](), NewScript[struct{}]()
+
+		errs := make(chan error, 1)
+		go func() {
+			_, err := async.NewA(s1.Action(), s2.Action()).Wait(context.Background())
+			errs <- err
+		}()
+
+		<-s1.Started()
+		<-s2.Started()
+		s2.Succeed(struct{}{})
+		s1.Succeed(struct{}{})
+		require.NoError(t, <-errs)
+		require.Equal(t, 1, s1.Calls())
+	})
+}
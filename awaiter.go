@@ -4,94 +4,81 @@ import (
 	"context"
 )
 
+// Awaiter waits for actions. It is safe to add actions concurrently, even from the actions that are running. By default
+// actions that are added during a Wait/WaitAny/WaitN are started in the next one, see WithDynamic.
+//
+// An Awaiter can be reused: all actions are started again in each Wait/WaitAny/WaitN, unless WithMemoize is used.
+//
+// The panics of actions are recovered and returned as their errors, which wrap ErrPanic.
 type Awaiter interface {
 	// Add add an action
 	Add(action Action)
+	// AddNamed add an action with name, see TaskInfo
+	AddNamed(name string, action Action)
+	// WithOptions apply options to the awaiter
+	WithOptions(opts ...Option) Awaiter
+	// Len get the number of actions
+	Len() int
+	// Remove remove the action at index i, return false if it doesn't exist
+	Remove(i int) bool
+	// Reset forget the cached results, so all actions are started again in next wait, see WithMemoize
+	Reset()
 	// Wait wail for all actions to completed
 	Wait(context.Context) ([]error, error)
 	// WaitAny wait for any action to completed without error, can cancel other tasks
 	WaitAny(context.Context) ([]error, error)
-	// WaitN wait for N actions to completed without error
+	// WaitN wait for N actions to completed without error. The actions that are still running are canceled with
+	// ErrQuorumReached once N actions are completed, or with ErrSiblingFailed once N actions can't be completed any more.
+	// The cause of ctx is returned if it is canceled before that, see context.Cause.
 	WaitN(context.Context, int) ([]error, error)
 }
 
+// awaiter runs actions as tasks without result on a waiter
 type awaiter struct {
-	actions []Action
+	w *waiter[struct{}]
 }
 
-func (a *awaiter) Add(action Action) {
-	a.actions = append(a.actions, action)
+func (a *awaiter) WithOptions(opts ...Option) Awaiter {
+	a.w.WithOptions(opts...)
+	return a
 }
 
-func (a *awaiter) Wait(ctx context.Context) ([]error, error) {
-	wait := make(chan error)
-
-	for _, action := range a.actions {
-		go func(action Action) {
+func (a *awaiter) Len() int {
+	return a.w.Len()
+}
 
-			wait <- action(ctx)
-		}(action)
-	}
+func (a *awaiter) Remove(i int) bool {
+	return a.w.Remove(i)
+}
 
-	var taskErrs []error
+func (a *awaiter) Reset() {
+	a.w.Reset()
+}
 
-	tt := len(a.actions)
-	for i := 0; i < tt; i++ {
-		select {
-		case err := <-wait:
-			if err != nil {
-				taskErrs = append(taskErrs, err)
-			}
-		case <-ctx.Done():
-			return taskErrs, ctx.Err()
-		}
-	}
+func (a *awaiter) Add(action Action) {
+	a.w.Add(toTask(action))
+}
 
-	if len(taskErrs) > 0 {
-		return taskErrs, ErrTooLessDone
-	}
+func (a *awaiter) AddNamed(name string, action Action) {
+	a.w.AddNamed(name, toTask(action))
+}
 
-	return taskErrs, nil
+func (a *awaiter) Wait(ctx context.Context) ([]error, error) {
+	_, taskErrs, err := a.w.Wait(ctx)
+	return taskErrs, err
 }
 
 func (a *awaiter) WaitN(ctx context.Context, n int) ([]error, error) {
-	wait := make(chan error)
-
-	cancelCtx, cancel := context.WithCancel(ctx)
-	defer cancel()
-
-	for _, action := range a.actions {
-		go func(action Action) {
-			wait <- action(cancelCtx)
-
-		}(action)
-	}
-
-	var taskErrs []error
-	tt := len(a.actions)
-
-	var done int
-	for i := 0; i < tt; i++ {
-		select {
-		case err := <-wait:
-			if err != nil {
-				taskErrs = append(taskErrs, err)
-			} else {
-
-				done++
-				if done == n {
-					return taskErrs, nil
-				}
-			}
-		case <-ctx.Done():
-			return taskErrs, ctx.Err()
-		}
-
-	}
-
-	return taskErrs, ErrTooLessDone
+	_, taskErrs, err := a.w.WaitN(ctx, n)
+	return taskErrs, err
 }
 
 func (a *awaiter) WaitAny(ctx context.Context) ([]error, error) {
 	return a.WaitN(ctx, 1)
 }
+
+func toTask(action Action) Task[struct{}] {
+	return func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, action(ctx)
+	}
+}
@@ -0,0 +1,280 @@
+package async_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yaitoo/async"
+	"github.com/yaitoo/async/asynctest"
+)
+
+// detachedA run action without the cancellation of Wait/WaitAny/WaitN, like a slow action that ignores its context
+func detachedA(action async.Action) async.Action {
+	return func(ctx context.Context) error {
+		return action(context.WithoutCancel(ctx))
+	}
+}
+
+func TestAwait(t *testing.T) {
+
+	wantedErr := errors.New("wanted")
+
+	fast := func(err error) async.Action {
+		return func(ctx context.Context) error {
+			return err
+		}
+	}
+
+	tests := []struct {
+		name       string
+		setup      func(slow async.Action) async.Awaiter
+		scripted   scripted
+		wantedErr  error
+		wantedErrs []error
+	}{
+		{
+			name: "wait_should_work",
+			setup: func(slow async.Action) async.Awaiter {
+				a := async.NewA(fast(nil), fast(nil))
+				a.Add(fast(nil))
+				return a
+			},
+			wantedErr: nil,
+		},
+		{
+			name: "error_should_work",
+			setup: func(slow async.Action) async.Awaiter {
+				return async.NewA(fast(nil), fast(nil), fast(wantedErr))
+			},
+			wantedErr:  async.ErrTooLessDone,
+			wantedErrs: []error{wantedErr},
+		},
+		{
+			name: "errors_should_work",
+			setup: func(slow async.Action) async.Awaiter {
+				return async.NewA(fast(wantedErr), fast(wantedErr), fast(wantedErr))
+			},
+			wantedErr:  async.ErrTooLessDone,
+			wantedErrs: []error{wantedErr, wantedErr, wantedErr},
+		},
+		{
+			name: "slow_should_be_waited",
+			setup: func(slow async.Action) async.Awaiter {
+				return async.NewA(slow, slow, fast(nil))
+			},
+			scripted: scripted{fast: 1, results: []int{0, 0}},
+		},
+		{
+			name: "context_should_work",
+			setup: func(slow async.Action) async.Awaiter {
+				return async.NewA(slow, slow, fast(nil))
+			},
+			scripted:  scripted{fast: 1, cause: context.DeadlineExceeded},
+			wantedErr: context.DeadlineExceeded,
+		},
+		{
+			name: "cancel_should_work",
+			setup: func(slow async.Action) async.Awaiter {
+				return async.NewA(slow, slow, fast(nil))
+			},
+			scripted:  scripted{fast: 1, cause: context.Canceled},
+			wantedErr: context.Canceled,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			slow := asynctest.NewScript[int]()
+			a := test.setup(detachedA(slow.Action()))
+
+			var err error
+			var taskErrs []error
+
+			test.scripted.run(slow, a.Len(), func(ctx context.Context, progress async.Option) {
+				taskErrs, err = a.WithOptions(progress).Wait(ctx)
+			})
+
+			require.Equal(t, test.wantedErr, err)
+			require.Equal(t, test.wantedErrs, taskErrs)
+		})
+	}
+}
+
+func TestAwaitAny(t *testing.T) {
+
+	wantedErr := errors.New("wanted")
+
+	fast := func(err error) async.Action {
+		return func(ctx context.Context) error {
+			return err
+		}
+	}
+
+	tests := []struct {
+		name       string
+		setup      func(slow async.Action) async.Awaiter
+		scripted   scripted
+		wantedErr  error
+		wantedErrs []error
+	}{
+		{
+			name: "1st_should_work",
+			setup: func(slow async.Action) async.Awaiter {
+				a := async.NewA(slow, slow)
+				a.Add(fast(nil))
+				return a
+			},
+		},
+		{
+			name: "2nd_should_work",
+			setup: func(slow async.Action) async.Awaiter {
+				return async.NewA(slow, fast(nil), slow)
+			},
+		},
+		{
+			name: "3rd_should_work",
+			setup: func(slow async.Action) async.Awaiter {
+				return async.NewA(slow, slow, fast(nil))
+			},
+		},
+		{
+			name: "slowest_should_work",
+			setup: func(slow async.Action) async.Awaiter {
+				return async.NewA(slow, fast(wantedErr), fast(wantedErr))
+			},
+			scripted:   scripted{fast: 2, results: []int{0}},
+			wantedErrs: []error{wantedErr, wantedErr},
+		},
+		{
+			name: "fastest_should_work",
+			setup: func(slow async.Action) async.Awaiter {
+				return async.NewA(fast(nil), slow, slow)
+			},
+		},
+		{
+			name: "errors_should_work",
+			setup: func(slow async.Action) async.Awaiter {
+				return async.NewA(fast(wantedErr), fast(wantedErr), fast(wantedErr))
+			},
+			wantedErr:  async.ErrTooLessDone,
+			wantedErrs: []error{wantedErr, wantedErr, wantedErr},
+		},
+		{
+			name: "error_should_work",
+			setup: func(slow async.Action) async.Awaiter {
+				return async.NewA(fast(wantedErr))
+			},
+			wantedErr:  async.ErrTooLessDone,
+			wantedErrs: []error{wantedErr},
+		},
+		{
+			name: "context_should_work",
+			setup: func(slow async.Action) async.Awaiter {
+				return async.NewA(slow, slow, slow)
+			},
+			scripted:  scripted{cause: context.DeadlineExceeded},
+			wantedErr: context.DeadlineExceeded,
+		},
+		{
+			name: "cancel_should_work",
+			setup: func(slow async.Action) async.Awaiter {
+				return async.NewA(slow, slow, slow)
+			},
+			scripted:  scripted{cause: context.Canceled},
+			wantedErr: context.Canceled,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			slow := asynctest.NewScript[int]()
+			a := test.setup(detachedA(slow.Action()))
+
+			var err error
+			var taskErrs []error
+
+			test.scripted.run(slow, a.Len(), func(ctx context.Context, progress async.Option) {
+				taskErrs, err = a.WithOptions(progress).WaitAny(ctx)
+			})
+
+			require.Equal(t, test.wantedErr, err)
+			require.Equal(t, test.wantedErrs, taskErrs)
+		})
+	}
+}
+
+func TestAwaitN(t *testing.T) {
+
+	wantedErr := errors.New("wanted")
+
+	fast := func(err error) async.Action {
+		return func(ctx context.Context) error {
+			return err
+		}
+	}
+
+	tests := []struct {
+		name       string
+		setup      func(slow async.Action) async.Awaiter
+		scripted   scripted
+		wantedN    int
+		wantedErr  error
+		wantedErrs []error
+	}{
+		{
+			name: "wait_n_should_work",
+			setup: func(slow async.Action) async.Awaiter {
+				a := async.NewA(fast(nil), fast(nil))
+				a.Add(slow)
+				return a
+			},
+			wantedN: 2,
+		},
+		{
+			name: "error_n_should_work",
+			setup: func(slow async.Action) async.Awaiter {
+				return async.NewA(slow, slow, fast(wantedErr))
+			},
+			scripted:   scripted{fast: 1, results: []int{0, 0}},
+			wantedN:    2,
+			wantedErrs: []error{wantedErr},
+		},
+		{
+			name: "context_should_work",
+			setup: func(slow async.Action) async.Awaiter {
+				return async.NewA(slow, slow, fast(nil))
+			},
+			scripted:  scripted{fast: 1, cause: context.DeadlineExceeded},
+			wantedN:   2,
+			wantedErr: context.DeadlineExceeded,
+		},
+		{
+			name: "cancel_should_work",
+			setup: func(slow async.Action) async.Awaiter {
+				return async.NewA(slow, slow, fast(nil))
+			},
+			scripted:  scripted{fast: 1, cause: context.Canceled},
+			wantedN:   2,
+			wantedErr: context.Canceled,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			slow := asynctest.NewScript[int]()
+			a := test.setup(detachedA(slow.Action()))
+
+			var err error
+			var taskErrs []error
+
+			test.scripted.run(slow, a.Len(), func(ctx context.Context, progress async.Option) {
+				taskErrs, err = a.WithOptions(progress).WaitN(ctx, test.wantedN)
+			})
+
+			require.Equal(t, test.wantedErr, err)
+			require.Equal(t, test.wantedErrs, taskErrs)
+		})
+	}
+}
@@ -0,0 +1,281 @@
+package async
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned by the tasks/actions that are protected by a Breaker when it is open
+var ErrCircuitOpen = errors.New("async: circuit breaker is open")
+
+// BreakerState the state of a Breaker
+type BreakerState int
+
+const (
+	// BreakerClosed all calls are allowed, and failures are counted in the rolling window
+	BreakerClosed BreakerState = iota
+	// BreakerOpen all calls are rejected with ErrCircuitOpen until OpenTimeout is passed
+	BreakerOpen
+	// BreakerHalfOpen a few calls are allowed to probe whether the dependency is recovered
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	}
+
+	return fmt.Sprintf("BreakerState(%d)", int(s))
+}
+
+// BreakerConfig the config of a Breaker, zero values are replaced by defaults
+type BreakerConfig struct {
+	// Window is the rolling window that failures are counted in, 10s by default
+	Window time.Duration
+	// FailureThreshold is the number of failures in Window to open the breaker, 5 by default
+	FailureThreshold int
+	// FailureRatio is the min ratio of failures to all calls in Window to open the breaker, it is ignored if it is zero
+	FailureRatio float64
+	// OpenTimeout is how long the breaker stays open before it is half-open, 30s by default
+	OpenTimeout time.Duration
+	// HalfOpenCalls is the number of calls that are allowed in half-open state. The breaker is closed once all of them are
+	// succeeded, and is open again on any failure. 1 by default.
+	HalfOpenCalls int
+	// OnStateChange is called when the state is changed, e.g. for alerting
+	OnStateChange func(from, to BreakerState)
+}
+
+const breakerBuckets = 10
+
+type bucket struct {
+	// at is the index of time slot that the bucket is counted for
+	at        int64
+	successes int
+	failures  int
+}
+
+// Breaker a circuit breaker with closed/open/half-open states, see Protect and ProtectA
+type Breaker struct {
+	cfg BreakerConfig
+
+	mu sync.Mutex
+	// clock is the Clock of the latest call, State is evaluated by it
+	clock    Clock
+	state    BreakerState
+	buckets  [breakerBuckets]bucket
+	openedAt time.Time
+	// probes is the number of calls that are allowed in half-open state, and passed is the number of succeeded ones
+	probes int
+	passed int
+	// period is increased when the breaker is half-open, so the probes of current half-open state can be told apart
+	period uint64
+}
+
+// NewBreaker create a Breaker with cfg
+func NewBreaker(cfg BreakerConfig) *Breaker {
+	if cfg.Window < breakerBuckets {
+		cfg.Window = 10 * time.Second
+	}
+	if cfg.FailureThreshold < 1 {
+		cfg.FailureThreshold = 5
+	}
+	if cfg.OpenTimeout <= 0 {
+		cfg.OpenTimeout = 30 * time.Second
+	}
+	if cfg.HalfOpenCalls < 1 {
+		cfg.HalfOpenCalls = 1
+	}
+
+	return &Breaker{cfg: cfg, clock: realClock{}}
+}
+
+// State get current state by the Clock of the latest call, see ClockFrom
+func (b *Breaker) State() BreakerState {
+	b.mu.Lock()
+	from := b.state
+	to := b.refresh(b.clock.Now())
+	b.mu.Unlock()
+
+	b.notify(from, to)
+	return to
+}
+
+// Do call fn if the breaker allows, and record its outcome. ErrCircuitOpen is returned if it is rejected.
+// The errors when ctx is canceled are not counted as failures, e.g. the tasks that are canceled by WaitAny. The rolling
+// window and OpenTimeout are measured by the Clock of ctx.
+func (b *Breaker) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	clock := ClockFrom(ctx)
+	probe, err := b.allow(clock)
+	if err != nil {
+		return err
+	}
+
+	err = fn(ctx)
+	if err != nil && ctx.Err() != nil {
+		b.cancel(probe)
+		return err
+	}
+
+	b.record(clock.Now(), probe, err)
+	return err
+}
+
+// Protect protect task by b, it returns ErrCircuitOpen without calling task when b is open
+func Protect[T any](task Task[T], b *Breaker) Task[T] {
+	return func(ctx context.Context) (T, error) {
+		var t T
+		err := b.Do(ctx, func(ctx context.Context) error {
+			var err error
+			t, err = task(ctx)
+			return err
+		})
+		return t, err
+	}
+}
+
+// ProtectA protect action by b, it returns ErrCircuitOpen without calling action when b is open
+func ProtectA(action Action, b *Breaker) Action {
+	return func(ctx context.Context) error {
+		return b.Do(ctx, action)
+	}
+}
+
+// allow check whether a call is allowed. probe is the half-open period that the call is allowed as a probe in, it is 0
+// if the call is not a probe.
+func (b *Breaker) allow(clock Clock) (probe uint64, err error) {
+	b.mu.Lock()
+	b.clock = clock
+	from := b.state
+	to := b.refresh(clock.Now())
+
+	switch to {
+	case BreakerOpen:
+		err = ErrCircuitOpen
+	case BreakerHalfOpen:
+		if b.probes >= b.cfg.HalfOpenCalls {
+			err = ErrCircuitOpen
+		} else {
+			b.probes++
+			probe = b.period
+		}
+	}
+	b.mu.Unlock()
+
+	b.notify(from, to)
+	return probe, err
+}
+
+// isProbe check whether probe is allowed in current half-open state, it must be called with mu held
+func (b *Breaker) isProbe(probe uint64) bool {
+	return b.state == BreakerHalfOpen && probe != 0 && probe == b.period
+}
+
+// cancel give back the probe of a call that is canceled
+func (b *Breaker) cancel(probe uint64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.isProbe(probe) {
+		b.probes--
+	}
+}
+
+// record count the outcome of a call. In half-open state, only the outcomes of its probes are counted, the calls that
+// are allowed before, e.g. when the breaker was closed, are ignored.
+func (b *Breaker) record(now time.Time, probe uint64, err error) {
+	b.mu.Lock()
+	from := b.state
+
+	switch b.state {
+	case BreakerClosed:
+		bk := b.bucket(now)
+		if err != nil {
+			bk.failures++
+		} else {
+			bk.successes++
+		}
+
+		if b.tripped(now) {
+			b.open(now)
+		}
+	case BreakerHalfOpen:
+		if !b.isProbe(probe) {
+			break
+		}
+		if err != nil {
+			b.open(now)
+		} else {
+			b.passed++
+			if b.passed >= b.cfg.HalfOpenCalls {
+				b.state = BreakerClosed
+				b.buckets = [breakerBuckets]bucket{}
+			}
+		}
+	}
+
+	to := b.state
+	b.mu.Unlock()
+
+	b.notify(from, to)
+}
+
+// refresh switch open state to half-open once OpenTimeout is passed, it must be called with mu held
+func (b *Breaker) refresh(now time.Time) BreakerState {
+	if b.state == BreakerOpen && now.Sub(b.openedAt) >= b.cfg.OpenTimeout {
+		b.state = BreakerHalfOpen
+		b.probes = 0
+		b.passed = 0
+		b.period++
+	}
+
+	return b.state
+}
+
+// open must be called with mu held
+func (b *Breaker) open(now time.Time) {
+	b.state = BreakerOpen
+	b.openedAt = now
+}
+
+// bucket get the bucket of now in the rolling window, it must be called with mu held
+func (b *Breaker) bucket(now time.Time) *bucket {
+	at := now.UnixNano() / int64(b.cfg.Window/breakerBuckets)
+	bk := &b.buckets[at%breakerBuckets]
+	if bk.at != at {
+		*bk = bucket{at: at}
+	}
+	return bk
+}
+
+// tripped check failures in the rolling window, it must be called with mu held
+func (b *Breaker) tripped(now time.Time) bool {
+	at := now.UnixNano() / int64(b.cfg.Window/breakerBuckets)
+
+	var successes, failures int
+	for _, bk := range b.buckets {
+		if at-bk.at < breakerBuckets {
+			successes += bk.successes
+			failures += bk.failures
+		}
+	}
+
+	if failures < b.cfg.FailureThreshold {
+		return false
+	}
+
+	return b.cfg.FailureRatio <= 0 || float64(failures)/float64(successes+failures) >= b.cfg.FailureRatio
+}
+
+func (b *Breaker) notify(from, to BreakerState) {
+	if from != to && b.cfg.OnStateChange != nil {
+		b.cfg.OnStateChange(from, to)
+	}
+}
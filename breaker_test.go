@@ -0,0 +1,191 @@
+package async
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBreaker(t *testing.T) {
+
+	wantedErr := errors.New("wanted")
+
+	failed := func(ctx context.Context) (int, error) {
+		return 0, wantedErr
+	}
+	succeeded := func(ctx context.Context) (int, error) {
+		return 1, nil
+	}
+
+	t.Run("state_should_work", func(t *testing.T) {
+		var changes []string
+		b := NewBreaker(BreakerConfig{
+			FailureThreshold: 2,
+			OpenTimeout:      20 * time.Millisecond,
+			HalfOpenCalls:    2,
+			OnStateChange: func(from, to BreakerState) {
+				changes = append(changes, from.String()+" -> "+to.String())
+			},
+		})
+
+		ctx := context.Background()
+		_, err := Protect(failed, b)(ctx)
+		require.Equal(t, wantedErr, err)
+		require.Equal(t, BreakerClosed, b.State())
+
+		_, err = Protect(failed, b)(ctx)
+		require.Equal(t, wantedErr, err)
+		require.Equal(t, BreakerOpen, b.State())
+
+		var called bool
+		_, err = Protect(func(ctx context.Context) (int, error) {
+			called = true
+			return 1, nil
+		}, b)(ctx)
+		require.Equal(t, ErrCircuitOpen, err)
+		require.False(t, called)
+
+		time.Sleep(20 * time.Millisecond)
+		require.Equal(t, BreakerHalfOpen, b.State())
+
+		_, err = Protect(succeeded, b)(ctx)
+		require.NoError(t, err)
+		require.Equal(t, BreakerHalfOpen, b.State())
+		_, err = Protect(succeeded, b)(ctx)
+		require.NoError(t, err)
+		require.Equal(t, BreakerClosed, b.State())
+
+		require.Equal(t, []string{"closed -> open", "open -> half-open", "half-open -> closed"}, changes)
+	})
+
+	t.Run("open_timeout_should_use_clock", func(t *testing.T) {
+		clock := &fixedClock{now: time.Date(2024, 3, 18, 0, 0, 0, 0, time.UTC)}
+		ctx := ContextWithClock(context.Background(), clock)
+
+		b := NewBreaker(BreakerConfig{
+			FailureThreshold: 1,
+			OpenTimeout:      time.Minute,
+		})
+
+		_, err := Protect(failed, b)(ctx)
+		require.Equal(t, wantedErr, err)
+		require.Equal(t, BreakerOpen, b.State())
+
+		clock.now = clock.now.Add(59 * time.Second)
+		_, err = Protect(succeeded, b)(ctx)
+		require.Equal(t, ErrCircuitOpen, err)
+
+		clock.now = clock.now.Add(time.Second)
+		require.Equal(t, BreakerHalfOpen, b.State())
+		_, err = Protect(succeeded, b)(ctx)
+		require.NoError(t, err)
+		require.Equal(t, BreakerClosed, b.State())
+	})
+
+	t.Run("half_open_should_work", func(t *testing.T) {
+		b := NewBreaker(BreakerConfig{
+			FailureThreshold: 1,
+			OpenTimeout:      time.Millisecond,
+		})
+
+		a := ProtectA(func(ctx context.Context) error {
+			return wantedErr
+		}, b)
+
+		require.Equal(t, wantedErr, a(context.Background()))
+		time.Sleep(time.Millisecond)
+
+		// only 1 call is allowed to probe, and it opens the breaker again on failure
+		probe, err := b.allow(realClock{})
+		require.NoError(t, err)
+		_, err = b.allow(realClock{})
+		require.Equal(t, ErrCircuitOpen, err)
+		b.record(time.Now(), probe, wantedErr)
+		require.Equal(t, BreakerOpen, b.State())
+	})
+
+	t.Run("only_probes_should_close", func(t *testing.T) {
+		clock := &fixedClock{now: time.Date(2024, 3, 18, 0, 0, 0, 0, time.UTC)}
+		b := NewBreaker(BreakerConfig{
+			FailureThreshold: 1,
+			OpenTimeout:      time.Minute,
+			HalfOpenCalls:    2,
+		})
+
+		// a slow call that is allowed while the breaker is closed
+		slow, err := b.allow(clock)
+		require.NoError(t, err)
+
+		_, err = b.allow(clock)
+		require.NoError(t, err)
+		b.record(clock.now, 0, wantedErr)
+		require.Equal(t, BreakerOpen, b.State())
+
+		clock.now = clock.now.Add(time.Minute)
+		probe, err := b.allow(clock)
+		require.NoError(t, err)
+		require.Equal(t, BreakerHalfOpen, b.State())
+
+		// the slow call isn't counted as a probe
+		b.record(clock.now, slow, nil)
+		b.record(clock.now, probe, nil)
+		require.Equal(t, BreakerHalfOpen, b.State())
+
+		// and it can't give back a probe
+		b.cancel(slow)
+		_, err = b.allow(clock)
+		require.NoError(t, err)
+		_, err = b.allow(clock)
+		require.Equal(t, ErrCircuitOpen, err)
+	})
+
+	t.Run("ratio_should_work", func(t *testing.T) {
+		b := NewBreaker(BreakerConfig{
+			FailureThreshold: 2,
+			FailureRatio:     0.5,
+		})
+
+		now := time.Now()
+		b.record(now, 0, nil)
+		b.record(now, 0, nil)
+		b.record(now, 0, nil)
+		b.record(now, 0, wantedErr)
+		b.record(now, 0, wantedErr)
+		require.Equal(t, BreakerClosed, b.State())
+		b.record(now, 0, wantedErr)
+		require.Equal(t, BreakerOpen, b.State())
+	})
+
+	t.Run("window_should_work", func(t *testing.T) {
+		b := NewBreaker(BreakerConfig{
+			Window:           time.Second,
+			FailureThreshold: 2,
+		})
+
+		now := time.Now()
+		b.record(now, 0, wantedErr)
+		b.record(now.Add(time.Second), 0, wantedErr)
+		require.Equal(t, BreakerClosed, b.State())
+		b.record(now.Add(1500*time.Millisecond), 0, wantedErr)
+		require.Equal(t, BreakerOpen, b.State())
+	})
+
+	t.Run("canceled_should_not_be_counted", func(t *testing.T) {
+		b := NewBreaker(BreakerConfig{
+			FailureThreshold: 1,
+		})
+
+		a := New[int](succeeded, Protect(func(ctx context.Context) (int, error) {
+			<-ctx.Done()
+			return 0, ctx.Err()
+		}, b))
+
+		_, _, err := a.WaitAny(context.Background())
+		require.NoError(t, err)
+		time.Sleep(10 * time.Millisecond)
+		require.Equal(t, BreakerClosed, b.State())
+	})
+}
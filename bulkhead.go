@@ -0,0 +1,140 @@
+package async
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+var (
+	// ErrBulkheadFull is returned by the tasks/actions that are rejected because their compartment and its queue are full
+	ErrBulkheadFull = errors.New("async: bulkhead is full")
+	// ErrUnknownBulkhead is returned by the tasks/actions that belong to a compartment that is not defined
+	ErrUnknownBulkhead = errors.New("async: unknown bulkhead")
+)
+
+type compartment struct {
+	slots chan struct{}
+
+	mu       sync.Mutex
+	queued   int
+	maxQueue int
+}
+
+// Bulkheads the named compartments that are isolated from each other, so a slow dependency can't monopolize all
+// goroutines. Each compartment has its own max concurrency and queue, see Isolate and IsolateA.
+type Bulkheads struct {
+	mu           sync.RWMutex
+	compartments map[string]*compartment
+}
+
+// NewBulkheads create an empty registry of compartments
+func NewBulkheads() *Bulkheads {
+	return &Bulkheads{
+		compartments: make(map[string]*compartment),
+	}
+}
+
+// Define define a compartment with name, at most maxConcurrent tasks/actions run in it at once and at most maxQueue
+// tasks/actions wait for them. It replaces the compartment with the same name for the tasks/actions that are not started.
+func (b *Bulkheads) Define(name string, maxConcurrent, maxQueue int) {
+	if maxConcurrent < 1 {
+		maxConcurrent = 1
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.compartments[name] = &compartment{
+		slots:    make(chan struct{}, maxConcurrent),
+		maxQueue: maxQueue,
+	}
+}
+
+// Stats get the number of running and queued tasks/actions in the compartment with name
+func (b *Bulkheads) Stats(name string) (running int, queued int) {
+	b.mu.RLock()
+	c, ok := b.compartments[name]
+	b.mu.RUnlock()
+
+	if !ok {
+		return 0, 0
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.slots), c.queued
+}
+
+// acquire get a slot in the compartment with name, it returns the function to release the slot
+func (b *Bulkheads) acquire(ctx context.Context, name string) (func(), error) {
+	b.mu.RLock()
+	c, ok := b.compartments[name]
+	b.mu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownBulkhead, name)
+	}
+
+	release := func() {
+		<-c.slots
+	}
+
+	select {
+	case c.slots <- struct{}{}:
+		return release, nil
+	default:
+	}
+
+	c.mu.Lock()
+	if c.queued >= c.maxQueue {
+		c.mu.Unlock()
+		return nil, fmt.Errorf("%w: %q", ErrBulkheadFull, name)
+	}
+	c.queued++
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		c.queued--
+		c.mu.Unlock()
+	}()
+
+	select {
+	case c.slots <- struct{}{}:
+		return release, nil
+	case <-ctx.Done():
+		return nil, context.Cause(ctx)
+	}
+}
+
+// Isolate run task in the compartment with name of b. It returns ErrBulkheadFull without calling task if the compartment
+// and its queue are full, so the rejection is reported in the errors of Waiter.
+func Isolate[T any](task Task[T], b *Bulkheads, name string) Task[T] {
+	return func(ctx context.Context) (T, error) {
+		release, err := b.acquire(ctx, name)
+		if err != nil {
+			var t T
+			return t, err
+		}
+		defer release()
+
+		return task(ctx)
+	}
+}
+
+// IsolateA run action in the compartment with name of b. It returns ErrBulkheadFull without calling action if the
+// compartment and its queue are full, so the rejection is reported in the errors of Awaiter.
+func IsolateA(action Action, b *Bulkheads, name string) Action {
+	return func(ctx context.Context) error {
+		release, err := b.acquire(ctx, name)
+		if err != nil {
+			return err
+		}
+		defer release()
+
+		return action(ctx)
+	}
+}
@@ -0,0 +1,110 @@
+package async
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBulkheads(t *testing.T) {
+
+	t.Run("isolate_should_work", func(t *testing.T) {
+		b := NewBulkheads()
+		b.Define("db", 2, 1)
+		b.Define("api", 1, 0)
+
+		var running, peak atomic.Int32
+		release := make(chan struct{})
+		rejected := make(chan struct{})
+		// running and queued of "db" when a call is rejected
+		stats := make(chan [2]int, 1)
+
+		slow := func(ctx context.Context) (int, error) {
+			if r := running.Add(1); r > peak.Load() {
+				peak.Store(r)
+			}
+			<-release
+			running.Add(-1)
+			return 1, nil
+		}
+
+		db := Isolate(slow, b, "db")
+
+		a := New[int]()
+		for i := 0; i < 4; i++ {
+			a.Add(func(ctx context.Context) (int, error) {
+				v, err := db(ctx)
+				if errors.Is(err, ErrBulkheadFull) {
+					r, q := b.Stats("db")
+					stats <- [2]int{r, q}
+					close(rejected)
+				}
+				return v, err
+			})
+		}
+		a.Add(Isolate(func(ctx context.Context) (int, error) {
+			return 2, nil
+		}, b, "api"))
+
+		go func() {
+			<-rejected
+			close(release)
+		}()
+
+		result, taskErrs, err := a.Wait(context.Background())
+		require.Equal(t, ErrTooLessDone, err)
+		require.Len(t, taskErrs, 1)
+		require.ErrorIs(t, taskErrs[0], ErrBulkheadFull)
+		require.ErrorContains(t, taskErrs[0], `"db"`)
+		require.Equal(t, [2]int{2, 1}, <-stats)
+		require.Len(t, result, 4)
+		require.Equal(t, int32(2), peak.Load())
+	})
+
+	t.Run("unknown_should_work", func(t *testing.T) {
+		err := IsolateA(func(ctx context.Context) error {
+			return nil
+		}, NewBulkheads(), "db")(context.Background())
+		require.ErrorIs(t, err, ErrUnknownBulkhead)
+	})
+
+	t.Run("cancel_should_work", func(t *testing.T) {
+		b := NewBulkheads()
+		b.Define("db", 1, 1)
+
+		wantedErr := errors.New("wanted")
+		ctx, cancel := context.WithCancelCause(context.Background())
+		blocked := make(chan struct{})
+
+		go func() {
+			_ = IsolateA(func(ctx context.Context) error {
+				close(blocked)
+				<-ctx.Done()
+				return nil
+			}, b, "db")(ctx)
+		}()
+
+		<-blocked
+		go func() {
+			for {
+				if _, q := b.Stats("db"); q == 1 {
+					cancel(wantedErr)
+					return
+				}
+				time.Sleep(time.Millisecond)
+			}
+		}()
+
+		err := IsolateA(func(ctx context.Context) error {
+			return nil
+		}, b, "db")(ctx)
+		require.Equal(t, wantedErr, err)
+
+		_, q := b.Stats("db")
+		require.Zero(t, q)
+	})
+}
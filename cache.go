@@ -0,0 +1,209 @@
+package async
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// entry a cached result in Cache
+type entry struct {
+	key     string
+	val     any
+	expires time.Time
+	// refreshing is true while the entry is refreshed in background
+	refreshing bool
+}
+
+// Cache an in-memory LRU cache of task results, see Cached. Concurrent loads of the same key share a single execution.
+type Cache struct {
+	maxEntries int
+	group      *Group
+
+	mu      sync.Mutex
+	lru     *list.List
+	entries map[string]*list.Element
+}
+
+// DefaultCache the Cache that is used by Cached unless WithCache is given
+var DefaultCache = NewCache(10000)
+
+// NewCache create a Cache that keeps at most maxEntries results, the least recently used ones are evicted once it is
+// full. It is unlimited if maxEntries is less than 1.
+func NewCache(maxEntries int) *Cache {
+	return &Cache{
+		maxEntries: maxEntries,
+		group:      NewGroup(),
+		lru:        list.New(),
+		entries:    make(map[string]*list.Element),
+	}
+}
+
+// Len get the number of cached results, including the expired ones that are not evicted yet
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.lru.Len()
+}
+
+// Delete delete the cached result of key
+func (c *Cache) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.entries[key]; ok {
+		c.remove(el)
+	}
+}
+
+// get get the entry of key unless it is older than stale after expiry, it must be called with mu held
+func (c *Cache) get(key string, now time.Time, stale time.Duration) *entry {
+	el, ok := c.entries[key]
+	if !ok {
+		return nil
+	}
+
+	e := el.Value.(*entry)
+	if !now.Before(e.expires.Add(stale)) {
+		c.remove(el)
+		return nil
+	}
+
+	c.lru.MoveToFront(el)
+	return e
+}
+
+func (c *Cache) set(key string, val any, expires time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.entries[key]; ok {
+		e := el.Value.(*entry)
+		e.val = val
+		e.expires = expires
+		c.lru.MoveToFront(el)
+		return
+	}
+
+	c.entries[key] = c.lru.PushFront(&entry{key: key, val: val, expires: expires})
+
+	if c.maxEntries > 0 && c.lru.Len() > c.maxEntries {
+		c.remove(c.lru.Back())
+	}
+}
+
+// remove must be called with mu held
+func (c *Cache) remove(el *list.Element) {
+	c.lru.Remove(el)
+	delete(c.entries, el.Value.(*entry).key)
+}
+
+// load run fn for key, and cache its result until ttl if it is completed without error
+func (c *Cache) load(ctx context.Context, key string, ttl time.Duration, fn func(ctx context.Context) (any, error)) (any, error) {
+	v, _, err := c.group.Do(ctx, key, func(ctx context.Context) (any, error) {
+		v, err := fn(ctx)
+		if err == nil {
+			c.set(key, v, ClockFrom(ctx).Now().Add(ttl))
+		}
+		return v, err
+	})
+	return v, err
+}
+
+// refresh load key in background unless it is being refreshed. The errors are ignored, so the cached result is used
+// until it is too stale.
+func (c *Cache) refresh(ctx context.Context, key string, ttl time.Duration, fn func(ctx context.Context) (any, error)) {
+	c.mu.Lock()
+	el, ok := c.entries[key]
+	if !ok || el.Value.(*entry).refreshing {
+		c.mu.Unlock()
+		return
+	}
+	e := el.Value.(*entry)
+	e.refreshing = true
+	c.mu.Unlock()
+
+	go func() {
+		_, _ = c.load(context.WithoutCancel(ctx), key, ttl, fn)
+
+		c.mu.Lock()
+		e.refreshing = false
+		c.mu.Unlock()
+	}()
+}
+
+// CacheOption configures Cached
+type CacheOption func(o *cacheOptions)
+
+type cacheOptions struct {
+	cache        *Cache
+	stale        time.Duration
+	refreshAhead time.Duration
+}
+
+// WithCache cache results in c instead of DefaultCache
+func WithCache(c *Cache) CacheOption {
+	return func(o *cacheOptions) {
+		o.cache = c
+	}
+}
+
+// WithStaleWhileRevalidate return the expired result for d after expiry, while it is refreshed in background
+func WithStaleWhileRevalidate(d time.Duration) CacheOption {
+	return func(o *cacheOptions) {
+		o.stale = d
+	}
+}
+
+// WithRefreshAhead refresh the result in background once it is used within d before expiry, so hot keys never expire
+func WithRefreshAhead(d time.Duration) CacheOption {
+	return func(o *cacheOptions) {
+		o.refreshAhead = d
+	}
+}
+
+// Cached cache the result of task by key for ttl, the errors are not cached. Concurrent calls with the same key share a
+// single execution of task when it is not cached, see Group. The keys should be unique in the Cache, e.g. they are
+// prefixed by the type of result, ErrTypeMismatch is returned if the result of key is another type.
+func Cached[T any](task Task[T], key string, ttl time.Duration, opts ...CacheOption) Task[T] {
+	o := cacheOptions{cache: DefaultCache}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	c := o.cache
+	fn := func(ctx context.Context) (any, error) {
+		return task(ctx)
+	}
+
+	return func(ctx context.Context) (T, error) {
+		now := ClockFrom(ctx).Now()
+
+		c.mu.Lock()
+		e := c.get(key, now, o.stale)
+		var v any
+		var refresh bool
+		if e != nil {
+			v = e.val
+			refresh = !now.Before(e.expires.Add(-o.refreshAhead))
+		}
+		c.mu.Unlock()
+
+		if e != nil {
+			if refresh {
+				c.refresh(ctx, key, ttl, fn)
+			}
+
+			return as[T](v, key)
+		}
+
+		v, err := c.load(ctx, key, ttl, fn)
+		t, terr := as[T](v, key)
+		if err == nil {
+			err = terr
+		}
+		return t, err
+	}
+}
@@ -0,0 +1,120 @@
+package async_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yaitoo/async"
+	"github.com/yaitoo/async/asynctest"
+)
+
+func TestCachedClock(t *testing.T) {
+
+	start := time.Date(2024, 3, 18, 0, 0, 0, 0, time.UTC)
+
+	t.Run("result_should_be_cached_until_ttl", func(t *testing.T) {
+		clock := asynctest.NewClock(start)
+		ctx := clock.Context(context.Background())
+		c := async.NewCache(0)
+
+		var calls atomic.Int32
+		task := async.Cached(func(ctx context.Context) (int, error) {
+			return int(calls.Add(1)), nil
+		}, "key", time.Minute, async.WithCache(c))
+
+		for i := 0; i < 3; i++ {
+			v, err := task(ctx)
+			require.NoError(t, err)
+			require.Equal(t, 1, v)
+		}
+
+		clock.Advance(59 * time.Second)
+		v, err := task(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 1, v)
+
+		clock.Advance(time.Second)
+		v, err = task(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 2, v)
+		require.Equal(t, 1, c.Len())
+
+		c.Delete("key")
+		v, err = task(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 3, v)
+	})
+
+	// the clock is not moved while the result is refreshed in background, so it can't expire meanwhile
+	refreshed := func(t *testing.T, task async.Task[int], ctx context.Context, wanted int) {
+		require.Eventually(t, func() bool {
+			v, _ := task(ctx)
+			return v == wanted
+		}, time.Second, time.Millisecond)
+	}
+
+	t.Run("stale_should_be_returned_while_revalidating", func(t *testing.T) {
+		clock := asynctest.NewClock(start)
+		ctx := clock.Context(context.Background())
+		c := async.NewCache(0)
+
+		var calls atomic.Int32
+		release := make(chan struct{})
+		task := async.Cached(func(ctx context.Context) (int, error) {
+			n := calls.Add(1)
+			if n > 1 {
+				<-release
+			}
+			return int(n), nil
+		}, "key", time.Minute, async.WithCache(c), async.WithStaleWhileRevalidate(time.Minute))
+
+		v, err := task(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 1, v)
+
+		clock.Advance(time.Minute)
+		for i := 0; i < 3; i++ {
+			v, err = task(ctx)
+			require.NoError(t, err)
+			require.Equal(t, 1, v)
+		}
+
+		close(release)
+		refreshed(t, task, ctx, 2)
+		require.Equal(t, int32(2), calls.Load())
+
+		// too stale to be returned
+		clock.Advance(2 * time.Minute)
+		v, err = task(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 3, v)
+	})
+
+	t.Run("hot_key_should_be_refreshed_ahead", func(t *testing.T) {
+		clock := asynctest.NewClock(start)
+		ctx := clock.Context(context.Background())
+		c := async.NewCache(0)
+
+		var calls atomic.Int32
+		task := async.Cached(func(ctx context.Context) (int, error) {
+			return int(calls.Add(1)), nil
+		}, "key", time.Minute, async.WithCache(c), async.WithRefreshAhead(10*time.Second))
+
+		v, err := task(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 1, v)
+
+		clock.Advance(49 * time.Second)
+		v, err = task(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 1, v)
+		require.Equal(t, int32(1), calls.Load())
+
+		clock.Advance(time.Second)
+		refreshed(t, task, ctx, 2)
+		require.Equal(t, int32(2), calls.Load())
+	})
+}
@@ -0,0 +1,89 @@
+package async
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCached(t *testing.T) {
+
+	wantedErr := errors.New("wanted")
+
+	t.Run("type_mismatch_should_be_returned", func(t *testing.T) {
+		c := NewCache(0)
+
+		v, err := Cached(func(ctx context.Context) (int, error) {
+			return 1, nil
+		}, "key", time.Minute, WithCache(c))(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, 1, v)
+
+		_, err = Cached(func(ctx context.Context) (string, error) {
+			return "1", nil
+		}, "key", time.Minute, WithCache(c))(context.Background())
+		require.ErrorIs(t, err, ErrTypeMismatch)
+	})
+
+	t.Run("errors_should_not_be_cached", func(t *testing.T) {
+		c := NewCache(0)
+
+		var calls atomic.Int32
+		task := Cached(func(ctx context.Context) (int, error) {
+			calls.Add(1)
+			return 0, wantedErr
+		}, "key", time.Minute, WithCache(c))
+
+		_, err := task(context.Background())
+		require.ErrorIs(t, err, wantedErr)
+		_, err = task(context.Background())
+		require.ErrorIs(t, err, wantedErr)
+		require.Equal(t, int32(2), calls.Load())
+		require.Zero(t, c.Len())
+	})
+
+	t.Run("concurrent_misses_should_be_shared", func(t *testing.T) {
+		var calls atomic.Int32
+		task := Cached(func(ctx context.Context) (int, error) {
+			calls.Add(1)
+			time.Sleep(20 * time.Millisecond)
+			return 1, nil
+		}, "concurrent_misses_should_be_shared", time.Minute)
+
+		result, _, err := New[int](task, task, task, task).Wait(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, []int{1, 1, 1, 1}, result)
+		require.Equal(t, int32(1), calls.Load())
+
+		DefaultCache.Delete("concurrent_misses_should_be_shared")
+	})
+
+	t.Run("least_recently_used_should_be_evicted", func(t *testing.T) {
+		c := NewCache(2)
+
+		var calls atomic.Int32
+		task := func(key string) Task[string] {
+			return Cached(func(ctx context.Context) (string, error) {
+				calls.Add(1)
+				return key, nil
+			}, key, time.Minute, WithCache(c))
+		}
+
+		ctx := context.Background()
+		_, _ = task("a")(ctx)
+		_, _ = task("b")(ctx)
+		_, _ = task("a")(ctx)
+		_, _ = task("c")(ctx)
+		require.Equal(t, 2, c.Len())
+		require.Equal(t, int32(3), calls.Load())
+
+		_, _ = task("a")(ctx)
+		require.Equal(t, int32(3), calls.Load())
+		_, _ = task("b")(ctx)
+		require.Equal(t, int32(4), calls.Load())
+	})
+}
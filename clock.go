@@ -0,0 +1,135 @@
+package async
+
+import (
+	"context"
+	"time"
+)
+
+// Clock the source of time that is used by Timeout, Retry, Scheduler, RateLimiter, Cached and the elapsed time of
+// Observer and Progress. It is carried by context, so tests can control time by a fake clock, see ContextWithClock.
+type Clock interface {
+	Now() time.Time
+	NewTimer(d time.Duration) Timer
+}
+
+// Timer a timer of Clock, like time.Timer
+type Timer interface {
+	// C get the channel that the time is sent to once the timer fires
+	C() <-chan time.Time
+	// Stop stop the timer, it returns false if the timer has fired or been stopped
+	Stop() bool
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) NewTimer(d time.Duration) Timer {
+	return realTimer{t: time.NewTimer(d)}
+}
+
+type realTimer struct {
+	t *time.Timer
+}
+
+func (t realTimer) C() <-chan time.Time {
+	return t.t.C
+}
+
+func (t realTimer) Stop() bool {
+	return t.t.Stop()
+}
+
+type clockKey struct{}
+
+// ContextWithClock get a copy of ctx that carries c
+func ContextWithClock(ctx context.Context, c Clock) context.Context {
+	return context.WithValue(ctx, clockKey{}, c)
+}
+
+// ClockFrom get the Clock that is carried by ctx, it is the system clock if there is none
+func ClockFrom(ctx context.Context) Clock {
+	if c, ok := ctx.Value(clockKey{}).(Clock); ok {
+		return c
+	}
+	return realClock{}
+}
+
+// timeoutCtx the context of withTimeout by a fake clock. It has its own Done channel, so the contexts that are derived
+// from it are canceled with its Err too.
+type timeoutCtx struct {
+	context.Context
+	deadline time.Time
+	done     chan struct{}
+}
+
+func (c *timeoutCtx) Deadline() (time.Time, bool) {
+	if d, ok := c.Context.Deadline(); ok && d.Before(c.deadline) {
+		return d, true
+	}
+	return c.deadline, true
+}
+
+func (c *timeoutCtx) Done() <-chan struct{} {
+	return c.done
+}
+
+func (c *timeoutCtx) Err() error {
+	select {
+	case <-c.done:
+	default:
+		return nil
+	}
+
+	if context.Cause(c.Context) == context.DeadlineExceeded {
+		return context.DeadlineExceeded
+	}
+	return c.Context.Err()
+}
+
+// withTimeout is context.WithTimeout by the clock of ctx. With a fake clock, ctx is canceled with the cause
+// context.DeadlineExceeded once the timer fires, and its Deadline is reported by the fake clock.
+func withTimeout(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	c := ClockFrom(ctx)
+	if _, ok := c.(realClock); ok {
+		return context.WithTimeout(ctx, d)
+	}
+
+	inner, cancel := context.WithCancelCause(ctx)
+	tc := &timeoutCtx{
+		Context:  inner,
+		deadline: c.Now().Add(d),
+		done:     make(chan struct{}),
+	}
+
+	timer := c.NewTimer(d)
+	go func() {
+		select {
+		case <-timer.C():
+			cancel(context.DeadlineExceeded)
+		case <-inner.Done():
+			timer.Stop()
+		}
+		close(tc.done)
+	}()
+
+	return tc, func() {
+		cancel(context.Canceled)
+		<-tc.done
+	}
+}
+
+// sleep wait for d by the clock of ctx, it returns false if ctx is canceled before that
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := ClockFrom(ctx).NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C():
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,76 @@
+package async
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fixedClock struct {
+	realClock
+	now time.Time
+}
+
+func (c fixedClock) Now() time.Time {
+	return c.now
+}
+
+func TestClock(t *testing.T) {
+
+	t.Run("system_clock_should_be_default", func(t *testing.T) {
+		require.Equal(t, realClock{}, ClockFrom(context.Background()))
+	})
+
+	t.Run("clock_should_be_carried_by_context", func(t *testing.T) {
+		now := time.Date(2024, 3, 18, 0, 0, 0, 0, time.UTC)
+		ctx := ContextWithClock(context.Background(), fixedClock{now: now})
+
+		var elapsed []time.Duration
+		_, err := NewA(func(ctx context.Context) error {
+			require.Equal(t, now, ClockFrom(ctx).Now())
+			return nil
+		}).WithOptions(WithProgress(func(p Progress) {
+			elapsed = append(elapsed, p.Elapsed)
+		})).Wait(ctx)
+
+		require.NoError(t, err)
+		require.Equal(t, []time.Duration{0}, elapsed)
+	})
+
+	t.Run("timeout_should_work_with_clock", func(t *testing.T) {
+		ctx := ContextWithClock(context.Background(), fixedClock{now: time.Now()})
+
+		_, err := Timeout(func(ctx context.Context) (int, error) {
+			<-ctx.Done()
+			return 0, context.Cause(ctx)
+		}, 10*time.Millisecond)(ctx)
+
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("timeout_context_should_work_with_clock", func(t *testing.T) {
+		now := time.Date(2024, 3, 18, 0, 0, 0, 0, time.UTC)
+		ctx := ContextWithClock(context.Background(), fixedClock{now: now})
+
+		timeoutCtx, cancel := withTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+		child, cancelChild := context.WithCancel(timeoutCtx)
+		defer cancelChild()
+
+		deadline, ok := timeoutCtx.Deadline()
+		require.True(t, ok)
+		require.Equal(t, now.Add(10*time.Millisecond), deadline)
+		require.NoError(t, timeoutCtx.Err())
+
+		<-child.Done()
+		require.Equal(t, context.DeadlineExceeded, timeoutCtx.Err())
+		require.Equal(t, context.DeadlineExceeded, context.Cause(timeoutCtx))
+		require.Equal(t, context.DeadlineExceeded, child.Err())
+
+		canceledCtx, cancel := withTimeout(ctx, time.Hour)
+		cancel()
+		require.Equal(t, context.Canceled, canceledCtx.Err())
+	})
+}
@@ -0,0 +1,93 @@
+package async
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCron(t *testing.T) {
+
+	at := func(s string) time.Time {
+		v, err := time.Parse("2006-01-02 15:04", s)
+		require.NoError(t, err)
+		return v
+	}
+
+	tests := []struct {
+		name string
+		expr string
+		from string
+		next []string
+	}{
+		{
+			name: "every_minute",
+			expr: "* * * * *",
+			from: "2024-03-18 10:00",
+			next: []string{"2024-03-18 10:01", "2024-03-18 10:02"},
+		},
+		{
+			name: "steps_and_ranges",
+			expr: "*/15 9-10 * * *",
+			from: "2024-03-18 10:40",
+			next: []string{"2024-03-18 10:45", "2024-03-19 09:00", "2024-03-19 09:15"},
+		},
+		{
+			name: "lists_and_names",
+			expr: "30 8 * jan,mar mon-fri",
+			from: "2024-03-29 09:00",
+			next: []string{"2025-01-01 08:30", "2025-01-02 08:30", "2025-01-03 08:30", "2025-01-06 08:30"},
+		},
+		{
+			name: "day_of_month_or_week",
+			expr: "0 0 1 * sun",
+			from: "2024-03-28 00:00",
+			next: []string{"2024-03-31 00:00", "2024-04-01 00:00", "2024-04-07 00:00"},
+		},
+		{
+			name: "sunday_is_7",
+			expr: "0 12 * * 7",
+			from: "2024-03-18 00:00",
+			next: []string{"2024-03-24 12:00"},
+		},
+		{
+			name: "descriptor",
+			expr: "@monthly",
+			from: "2024-02-10 00:00",
+			next: []string{"2024-03-01 00:00", "2024-04-01 00:00"},
+		},
+		{
+			name: "leap_day",
+			expr: "0 0 29 2 *",
+			from: "2024-03-01 00:00",
+			next: []string{"2028-02-29 00:00"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c, err := ParseCron(test.expr)
+			require.NoError(t, err)
+
+			now := at(test.from)
+			for _, next := range test.next {
+				now = c.Next(now)
+				require.Equal(t, at(next), now)
+			}
+		})
+	}
+
+	t.Run("impossible_should_be_zero", func(t *testing.T) {
+		c, err := ParseCron("0 0 30 2 *")
+		require.NoError(t, err)
+		require.True(t, c.Next(time.Now()).IsZero())
+	})
+
+	t.Run("invalid_should_fail", func(t *testing.T) {
+		for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * foo *", "5-1 * * * *", "*/0 * * * *"} {
+			_, err := ParseCron(expr)
+			require.ErrorIs(t, err, ErrInvalidCron, expr)
+		}
+	})
+}
@@ -0,0 +1,377 @@
+package async
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrDuplicateNode is returned when a node is added to a DAG with a name that is used already
+	ErrDuplicateNode = errors.New("async: duplicate node")
+	// ErrUnknownNode is returned when a node depends on a node that doesn't exist in the DAG
+	ErrUnknownNode = errors.New("async: unknown node")
+	// ErrCycle is returned when nodes of a DAG depend on each other
+	ErrCycle = errors.New("async: dependency cycle")
+	// ErrUpstreamFailed is the error of a node that is skipped because any of its upstream nodes is not succeeded
+	ErrUpstreamFailed = errors.New("async: upstream node failed")
+)
+
+// NodeStatus the status of a node in a DAG run
+type NodeStatus int
+
+const (
+	// NodePending the node is not started yet
+	NodePending NodeStatus = iota
+	// NodeSucceeded the node is completed without error
+	NodeSucceeded
+	// NodeFailed the node is completed with error
+	NodeFailed
+	// NodeSkipped the node is not started because any of its upstream nodes is not succeeded
+	NodeSkipped
+	// NodeCanceled the node is not completed because the context is canceled
+	NodeCanceled
+)
+
+func (s NodeStatus) String() string {
+	switch s {
+	case NodePending:
+		return "pending"
+	case NodeSucceeded:
+		return "succeeded"
+	case NodeFailed:
+		return "failed"
+	case NodeSkipped:
+		return "skipped"
+	case NodeCanceled:
+		return "canceled"
+	}
+
+	return fmt.Sprintf("NodeStatus(%d)", int(s))
+}
+
+// NodeReport the outcome of a node in a DAG run
+type NodeReport[T any] struct {
+	Name   string
+	Deps   []string
+	Status NodeStatus
+	Data   T
+	Error  error
+	// Started is zero if the node is not started
+	Started time.Time
+	Elapsed time.Duration
+}
+
+// Report the outcome of a DAG run
+type Report[T any] struct {
+	// Nodes are in the order that they are added to the DAG
+	Nodes   []NodeReport[T]
+	Elapsed time.Duration
+}
+
+// Node get the outcome of the node with name
+func (r *Report[T]) Node(name string) (NodeReport[T], bool) {
+	for _, n := range r.Nodes {
+		if n.Name == name {
+			return n, true
+		}
+	}
+
+	return NodeReport[T]{}, false
+}
+
+type node[T any] struct {
+	name     string
+	task     Task[T]
+	deps     []string
+	parents  []int
+	children []int
+	attempts int
+}
+
+// DAG runs named tasks by their dependencies with maximal parallelism. A node is started once all of its upstream
+// nodes are succeeded, and gets their results by Upstream. It is skipped if any of its upstream nodes is not succeeded.
+// The panics of nodes are recovered and reported as their errors, which wrap ErrPanic.
+type DAG[T any] struct {
+	id uint64
+
+	mu    sync.Mutex
+	nodes []*node[T]
+	index map[string]int
+	err   error
+	built bool
+}
+
+// NewDAG create a DAG of tasks
+func NewDAG[T any]() *DAG[T] {
+	return &DAG[T]{
+		id:    lastWaiterID.Add(1),
+		index: make(map[string]int),
+	}
+}
+
+// Add add a task as node with name, which depends on the nodes of deps. The nodes of deps can be added later.
+func (d *DAG[T]) Add(name string, task Task[T], deps ...string) *DAG[T] {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.built = false
+	if _, ok := d.index[name]; ok {
+		d.err = errors.Join(d.err, fmt.Errorf("%w: %q", ErrDuplicateNode, name))
+		return d
+	}
+
+	d.index[name] = len(d.nodes)
+	d.nodes = append(d.nodes, &node[T]{
+		name: name,
+		task: task,
+		deps: deps,
+	})
+
+	return d
+}
+
+// AddAction add an action as node with name, which depends on the nodes of deps. Its result is the zero value of T.
+func (d *DAG[T]) AddAction(name string, action Action, deps ...string) *DAG[T] {
+	return d.Add(name, func(ctx context.Context) (T, error) {
+		var t T
+		return t, action(ctx)
+	}, deps...)
+}
+
+// Build validate the nodes, and return the errors of duplicate nodes, unknown nodes and cycles if there are
+func (d *DAG[T]) Build() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.build()
+}
+
+// build must be called with mu held
+func (d *DAG[T]) build() error {
+	if d.built {
+		return d.err
+	}
+
+	err := d.err
+	for _, n := range d.nodes {
+		n.parents = nil
+		n.children = nil
+	}
+
+	for i, n := range d.nodes {
+		for _, dep := range n.deps {
+			p, ok := d.index[dep]
+			if !ok {
+				err = errors.Join(err, fmt.Errorf("%w: %q depends on %q", ErrUnknownNode, n.name, dep))
+				continue
+			}
+			n.parents = append(n.parents, p)
+			d.nodes[p].children = append(d.nodes[p].children, i)
+		}
+	}
+
+	if err == nil {
+		err = d.checkCycle()
+	}
+
+	d.err = err
+	d.built = true
+	return err
+}
+
+// cycleError the ErrCycle of a DAG, path starts and ends with the node whose deps close the cycle
+type cycleError struct {
+	path []string
+}
+
+func (e *cycleError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrCycle, strings.Join(e.path, " -> "))
+}
+
+func (e *cycleError) Unwrap() error {
+	return ErrCycle
+}
+
+// checkCycle find a cycle by depth-first search, must be called with mu held
+func (d *DAG[T]) checkCycle() error {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+
+	states := make([]int, len(d.nodes))
+	var path []string
+
+	var visit func(i int) error
+	visit = func(i int) error {
+		switch states[i] {
+		case visiting:
+			start := 0
+			for j, name := range path {
+				if name == d.nodes[i].name {
+					start = j
+				}
+			}
+			return &cycleError{path: append(append([]string{}, path[start:]...), d.nodes[i].name)}
+		case visited:
+			return nil
+		}
+
+		states[i] = visiting
+		path = append(path, d.nodes[i].name)
+		for _, c := range d.nodes[i].children {
+			if err := visit(c); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		states[i] = visited
+		return nil
+	}
+
+	for i := range d.nodes {
+		if err := visit(i); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type upstreamKey struct{}
+
+// Upstream get the result of the upstream node with name in the context that is passed to a node of DAG
+func Upstream[T any](ctx context.Context, name string) (T, bool) {
+	results, _ := ctx.Value(upstreamKey{}).(map[string]T)
+	t, ok := results[name]
+	return t, ok
+}
+
+// Run build and run all nodes, and report the outcome of each node. ErrTooLessDone is returned if any node is not
+// succeeded, and the cause of ctx is returned if it is canceled.
+func (d *DAG[T]) Run(ctx context.Context) (*Report[T], error) {
+	d.mu.Lock()
+	if err := d.build(); err != nil {
+		d.mu.Unlock()
+		return nil, err
+	}
+
+	// nodes are copied, so the DAG can be changed and run again during the run
+	nodes := make([]node[T], len(d.nodes))
+	for i, n := range d.nodes {
+		n.attempts++
+		nodes[i] = *n
+	}
+	d.mu.Unlock()
+
+	clock := ClockFrom(ctx)
+	started := clock.Now()
+	report := &Report[T]{
+		Nodes: make([]NodeReport[T], len(nodes)),
+	}
+
+	// waiting is the number of upstream nodes that are not completed yet
+	waiting := make([]int, len(nodes))
+	for i, n := range nodes {
+		waiting[i] = len(n.deps)
+		report.Nodes[i] = NodeReport[T]{
+			Name: n.name,
+			Deps: n.deps,
+		}
+	}
+
+	wait := make(chan Result[T], len(nodes))
+	running := 0
+
+	start := func(i int) {
+		n := nodes[i]
+		results := make(map[string]T, len(n.parents))
+		for _, p := range n.parents {
+			results[nodes[p].name] = report.Nodes[p].Data
+		}
+
+		taskCtx := withInfo(context.WithValue(ctx, upstreamKey{}, results), TaskInfo{
+			WaiterID: d.id,
+			Index:    i,
+			Name:     n.name,
+			Attempt:  n.attempts,
+		})
+
+		report.Nodes[i].Started = clock.Now()
+		running++
+		go func() {
+			v, err := try(taskCtx, n.task)
+			wait <- Result[T]{
+				Index: i,
+				Data:  v,
+				Error: err,
+			}
+		}()
+	}
+
+	// skip marks all downstream nodes of i as skipped
+	var skip func(i int)
+	skip = func(i int) {
+		for _, c := range nodes[i].children {
+			if report.Nodes[c].Status != NodePending {
+				continue
+			}
+			report.Nodes[c].Status = NodeSkipped
+			report.Nodes[c].Error = fmt.Errorf("%w: %q", ErrUpstreamFailed, nodes[i].name)
+			skip(c)
+		}
+	}
+
+	for i := range nodes {
+		if waiting[i] == 0 {
+			start(i)
+		}
+	}
+
+	var err error
+	for running > 0 {
+		select {
+		case r := <-wait:
+			running--
+			nr := &report.Nodes[r.Index]
+			nr.Elapsed = clock.Now().Sub(nr.Started)
+			nr.Data = r.Data
+			nr.Error = r.Error
+			if r.Error != nil {
+				nr.Status = NodeFailed
+				skip(r.Index)
+				continue
+			}
+
+			nr.Status = NodeSucceeded
+			for _, c := range nodes[r.Index].children {
+				waiting[c]--
+				if waiting[c] == 0 && report.Nodes[c].Status == NodePending {
+					start(c)
+				}
+			}
+		case <-ctx.Done():
+			err = context.Cause(ctx)
+			running = 0
+		}
+	}
+
+	for i := range report.Nodes {
+		nr := &report.Nodes[i]
+		if nr.Status == NodePending {
+			nr.Status = NodeCanceled
+			nr.Error = err
+		}
+		if err == nil && nr.Status != NodeSucceeded {
+			err = ErrTooLessDone
+		}
+	}
+
+	report.Elapsed = clock.Now().Sub(started)
+	return report, err
+}
@@ -0,0 +1,156 @@
+package async
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDAG(t *testing.T) {
+
+	wantedErr := errors.New("wanted")
+
+	value := func(v int) Task[int] {
+		return func(ctx context.Context) (int, error) {
+			return v, nil
+		}
+	}
+
+	sum := func(ctx context.Context) (int, error) {
+		info, _ := InfoFrom(ctx)
+		a, _ := Upstream[int](ctx, "a")
+		b, _ := Upstream[int](ctx, "b")
+		c, _ := Upstream[int](ctx, "c")
+		if info.Name == "d" {
+			return c * 10, nil
+		}
+		return a + b, nil
+	}
+
+	t.Run("run_should_work", func(t *testing.T) {
+		d := NewDAG[int]().
+			Add("d", sum, "c").
+			Add("c", sum, "a", "b").
+			Add("a", value(1)).
+			Add("b", value(2))
+
+		report, err := d.Run(context.Background())
+		require.NoError(t, err)
+
+		c, ok := report.Node("c")
+		require.True(t, ok)
+		require.Equal(t, NodeSucceeded, c.Status)
+		require.Equal(t, 3, c.Data)
+		require.Equal(t, []string{"a", "b"}, c.Deps)
+
+		n, _ := report.Node("d")
+		require.Equal(t, 30, n.Data)
+		require.Equal(t, "d", report.Nodes[0].Name)
+		require.False(t, n.Started.IsZero())
+	})
+
+	t.Run("report_should_use_clock", func(t *testing.T) {
+		now := time.Date(2024, 3, 18, 0, 0, 0, 0, time.UTC)
+		ctx := ContextWithClock(context.Background(), fixedClock{now: now})
+
+		report, err := NewDAG[int]().
+			Add("b", sum, "a").
+			Add("a", value(1)).
+			Run(ctx)
+		require.NoError(t, err)
+
+		for _, n := range report.Nodes {
+			require.Equal(t, now, n.Started)
+			require.Zero(t, n.Elapsed)
+		}
+		require.Zero(t, report.Elapsed)
+	})
+
+	t.Run("parallel_should_work", func(t *testing.T) {
+		var running, peak atomic.Int32
+		slow := func(ctx context.Context) (int, error) {
+			if r := running.Add(1); r > peak.Load() {
+				peak.Store(r)
+			}
+			time.Sleep(50 * time.Millisecond)
+			running.Add(-1)
+			return 0, nil
+		}
+
+		d := NewDAG[int]().Add("a", slow).Add("b", slow).Add("c", slow).Add("d", slow, "a", "b", "c")
+
+		_, err := d.Run(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, int32(3), peak.Load())
+	})
+
+	t.Run("skip_should_work", func(t *testing.T) {
+		d := NewDAG[int]().
+			Add("a", func(ctx context.Context) (int, error) {
+				return 0, wantedErr
+			}).
+			Add("b", value(2)).
+			Add("c", sum, "a", "b").
+			Add("d", sum, "c").
+			Add("e", value(5), "b")
+
+		report, err := d.Run(context.Background())
+		require.Equal(t, ErrTooLessDone, err)
+
+		wanted := map[string]NodeStatus{
+			"a": NodeFailed,
+			"b": NodeSucceeded,
+			"c": NodeSkipped,
+			"d": NodeSkipped,
+			"e": NodeSucceeded,
+		}
+		for _, n := range report.Nodes {
+			require.Equal(t, wanted[n.Name], n.Status, n.Name)
+		}
+
+		a, _ := report.Node("a")
+		require.Equal(t, wantedErr, a.Error)
+		c, _ := report.Node("c")
+		require.ErrorIs(t, c.Error, ErrUpstreamFailed)
+		require.True(t, c.Started.IsZero())
+	})
+
+	t.Run("cancel_should_work", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+
+		d := NewDAG[int]().
+			AddAction("a", func(ctx context.Context) error {
+				cancel()
+				<-ctx.Done()
+				return ctx.Err()
+			}).
+			Add("b", value(2), "a")
+
+		report, err := d.Run(ctx)
+		require.Equal(t, context.Canceled, err)
+
+		b, _ := report.Node("b")
+		require.Equal(t, NodeCanceled, b.Status)
+	})
+
+	t.Run("build_should_work", func(t *testing.T) {
+		err := NewDAG[int]().Add("a", value(1)).Add("a", value(1)).Build()
+		require.ErrorIs(t, err, ErrDuplicateNode)
+
+		err = NewDAG[int]().Add("a", value(1), "x").Build()
+		require.ErrorIs(t, err, ErrUnknownNode)
+		require.ErrorContains(t, err, `"a" depends on "x"`)
+
+		d := NewDAG[int]().Add("a", value(1), "c").Add("b", value(1), "a").Add("c", value(1), "b").Add("d", value(1))
+		err = d.Build()
+		require.ErrorIs(t, err, ErrCycle)
+		require.ErrorContains(t, err, "a -> b -> c -> a")
+
+		_, err = d.Run(context.Background())
+		require.ErrorIs(t, err, ErrCycle)
+	})
+}
@@ -0,0 +1,50 @@
+package async
+
+import (
+	"context"
+	"time"
+)
+
+// Timeout cancel the task if it is not completed in d
+func Timeout[T any](task Task[T], d time.Duration) Task[T] {
+	return func(ctx context.Context) (T, error) {
+		ctx, cancel := withTimeout(ctx, d)
+		defer cancel()
+
+		return task(ctx)
+	}
+}
+
+// Retry start the task again after backoff if it is failed, until it is completed without error or it has been started
+// attempts times. The Attempt of TaskInfo is increased in each retry. The last error is returned if all of them are failed.
+// The task is started at least once if attempts is less than 1.
+func Retry[T any](task Task[T], attempts int, backoff time.Duration) Task[T] {
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	return func(ctx context.Context) (T, error) {
+		info, _ := InfoFrom(ctx)
+		if info.Attempt == 0 {
+			info.Attempt = 1
+		}
+
+		var t T
+		var err error
+		for i := 0; i < attempts; i++ {
+			if i > 0 {
+				if !sleep(ctx, backoff) {
+					return t, err
+				}
+				info.Attempt++
+			}
+
+			t, err = task(withInfo(ctx, info))
+			if err == nil || ctx.Err() != nil {
+				return t, err
+			}
+		}
+
+		return t, err
+	}
+}
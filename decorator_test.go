@@ -0,0 +1,90 @@
+package async
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeout(t *testing.T) {
+	task := Timeout(func(ctx context.Context) (int, error) {
+		<-ctx.Done()
+		return 0, ctx.Err()
+	}, 10*time.Millisecond)
+
+	_, err := task(context.Background())
+	require.Equal(t, context.DeadlineExceeded, err)
+
+	task = Timeout(func(ctx context.Context) (int, error) {
+		return 1, nil
+	}, time.Second)
+
+	v, err := task(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, v)
+}
+
+func TestRetry(t *testing.T) {
+
+	wantedErr := errors.New("wanted")
+
+	t.Run("retry_should_work", func(t *testing.T) {
+		var attempts []int
+		task := Retry(func(ctx context.Context) (int, error) {
+			info, _ := InfoFrom(ctx)
+			attempts = append(attempts, info.Attempt)
+			if info.Attempt < 3 {
+				return 0, wantedErr
+			}
+			return info.Attempt, nil
+		}, 5, time.Millisecond)
+
+		v, err := task(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, 3, v)
+		require.Equal(t, []int{1, 2, 3}, attempts)
+	})
+
+	t.Run("attempts_should_work", func(t *testing.T) {
+		var started int
+		a := New[int](Retry(func(ctx context.Context) (int, error) {
+			started++
+			return 0, wantedErr
+		}, 3, time.Millisecond))
+
+		_, taskErrs, err := a.Wait(context.Background())
+		require.Equal(t, ErrTooLessDone, err)
+		require.Equal(t, []error{wantedErr}, taskErrs)
+		require.Equal(t, 3, started)
+	})
+
+	t.Run("less_than_1_attempt_should_start_once", func(t *testing.T) {
+		for _, attempts := range []int{0, -1} {
+			var started int
+			v, err := Retry(func(ctx context.Context) (int, error) {
+				started++
+				return 1, nil
+			}, attempts, time.Millisecond)(context.Background())
+			require.NoError(t, err)
+			require.Equal(t, 1, v)
+			require.Equal(t, 1, started)
+		}
+	})
+
+	t.Run("cancel_should_work", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		var started int
+		task := Retry(func(ctx context.Context) (int, error) {
+			started++
+			cancel()
+			return 0, wantedErr
+		}, 3, time.Hour)
+
+		_, err := task(ctx)
+		require.Equal(t, wantedErr, err)
+		require.Equal(t, 1, started)
+	})
+}
@@ -0,0 +1,162 @@
+package async
+
+import (
+	"math/rand"
+	"sync"
+)
+
+// Executor runs the tasks/actions of Waiter/Awaiter, see WithExecutor
+type Executor interface {
+	// Go run fn asynchronously, it must not wait for fn to be completed
+	Go(fn func())
+}
+
+// GoExecutor runs each function on a new goroutine, it is the default Executor
+type GoExecutor struct{}
+
+func (GoExecutor) Go(fn func()) {
+	go fn()
+}
+
+func (GoExecutor) submit(r runner) {
+	go r.run()
+}
+
+// runner a task/action of Waiter/Awaiter, it is submitted to the executors that implement submitter without wrapping it
+// in a closure
+type runner interface {
+	run()
+}
+
+// funcRunner run fn as runner, it is converted to runner without allocation
+type funcRunner func()
+
+func (fn funcRunner) run() {
+	fn()
+}
+
+// submitter is implemented by the executors that run runners directly
+type submitter interface {
+	submit(r runner)
+}
+
+// WorkerExecutor runs functions on a fixed number of reusable workers, so starting a task doesn't start a goroutine.
+// Functions are queued until a worker is free, and they are run on new goroutines once the queue is full. It can be
+// shared by multiple Waiters/Awaiters.
+//
+// The queued functions wait for the running ones, so a task that waits for other tasks on the same WorkerExecutor,
+// e.g. a nested Wait, can deadlock once all workers are taken by such tasks. Use a separate executor for them.
+type WorkerExecutor struct {
+	mu     sync.RWMutex
+	queue  chan runner
+	closed bool
+}
+
+// NewWorkerExecutor create a WorkerExecutor with workers and a queue of size
+func NewWorkerExecutor(workers, size int) *WorkerExecutor {
+	if workers < 1 {
+		workers = 1
+	}
+	if size < 0 {
+		size = 0
+	}
+
+	e := &WorkerExecutor{
+		queue: make(chan runner, size),
+	}
+
+	for i := 0; i < workers; i++ {
+		go e.worker()
+	}
+
+	return e
+}
+
+func (e *WorkerExecutor) Go(fn func()) {
+	e.submit(funcRunner(fn))
+}
+
+func (e *WorkerExecutor) submit(r runner) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	if !e.closed {
+		select {
+		case e.queue <- r:
+			return
+		default:
+		}
+	}
+
+	go r.run()
+}
+
+// Close stop the workers once the queued functions are completed. The functions that are run later are run on new
+// goroutines.
+func (e *WorkerExecutor) Close() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if !e.closed {
+		e.closed = true
+		close(e.queue)
+	}
+}
+
+func (e *WorkerExecutor) worker() {
+	for r := range e.queue {
+		r.run()
+	}
+}
+
+// Order the order that InlineExecutor runs tasks/actions in
+type Order int
+
+const (
+	// OrderDeclared runs tasks/actions in the order that they are added. It is the default order.
+	OrderDeclared Order = iota
+	// OrderReversed runs the last added task/action first
+	OrderReversed
+	// OrderShuffled runs tasks/actions in a random order that is reproduced by Seed
+	OrderShuffled
+)
+
+// InlineExecutor runs tasks/actions one by one on the goroutine that waits for them, in Order. So an interleaving, e.g.
+// which task wins WaitAny, can be reproduced exactly by running again with the same Order and Seed. The tasks/actions
+// that are not started when Wait/WaitAny/WaitN returns are never started.
+//
+// Each task/action runs to completion before the next one is started, so the ones that wait for their siblings to
+// complete or cancel them never return. It is meant for debugging and tests.
+type InlineExecutor struct {
+	Order Order
+	Seed  int64
+}
+
+// Go run fn on current goroutine. Waiter/Awaiter don't call it, they run tasks/actions by Order instead.
+func (e InlineExecutor) Go(fn func()) {
+	fn()
+}
+
+// inliner is implemented by the executors that run tasks/actions on the waiting goroutine
+type inliner interface {
+	// picker get a function that chooses the next one of n pending tasks/actions in a Wait/WaitAny/WaitN
+	picker() func(n int) int
+}
+
+func (e InlineExecutor) picker() func(n int) int {
+	switch e.Order {
+	case OrderReversed:
+		return func(n int) int {
+			return n - 1
+		}
+	case OrderShuffled:
+		rnd := rand.New(rand.NewSource(e.Seed))
+		return func(n int) int {
+			return rnd.Intn(n)
+		}
+	}
+
+	return func(n int) int {
+		return 0
+	}
+}
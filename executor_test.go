@@ -0,0 +1,287 @@
+package async
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecutor(t *testing.T) {
+
+	t.Run("worker_should_run_all", func(t *testing.T) {
+		e := NewWorkerExecutor(2, 4)
+		defer e.Close()
+
+		var wg sync.WaitGroup
+		var n atomic.Int32
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			e.Go(func() {
+				defer wg.Done()
+				n.Add(1)
+				time.Sleep(time.Millisecond)
+			})
+		}
+		wg.Wait()
+
+		require.Equal(t, int32(10), n.Load())
+	})
+
+	t.Run("full_queue_should_fall_back_to_goroutine", func(t *testing.T) {
+		e := NewWorkerExecutor(1, 0)
+		defer e.Close()
+
+		// the only worker is blocked until the next function is started
+		ready := make(chan struct{})
+		done := make(chan struct{})
+		e.Go(func() {
+			<-ready
+			close(done)
+		})
+		e.Go(func() {
+			close(ready)
+		})
+
+		<-done
+	})
+
+	t.Run("closed_should_still_run", func(t *testing.T) {
+		e := NewWorkerExecutor(1, 1)
+
+		done := make(chan struct{})
+		e.Go(func() {
+			done <- struct{}{}
+		})
+		<-done
+
+		e.Close()
+		e.Close()
+
+		e.Go(func() {
+			close(done)
+		})
+		<-done
+	})
+
+	t.Run("waiter_should_work", func(t *testing.T) {
+		e := NewWorkerExecutor(2, 16)
+		defer e.Close()
+
+		task := func(ctx context.Context) (int, error) {
+			return 1, nil
+		}
+
+		w := New[int](task, task, task).WithOptions(WithExecutor(e))
+		for i := 0; i < 3; i++ {
+			result, _, err := w.Wait(context.Background())
+			require.NoError(t, err)
+			require.Equal(t, []int{1, 1, 1}, result)
+		}
+
+		_, err := NewA(func(ctx context.Context) error {
+			return nil
+		}).WithOptions(WithExecutor(GoExecutor{})).Wait(context.Background())
+		require.NoError(t, err)
+	})
+
+	t.Run("blocking_executor_should_not_deadlock", func(t *testing.T) {
+		e := newBlockingExecutor()
+		defer close(e.fns)
+
+		var w Waiter[int]
+		task := func(ctx context.Context) (int, error) {
+			return w.Len(), nil
+		}
+		w = New[int](task, task, task).WithOptions(WithExecutor(e))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		result, _, err := w.Wait(ctx)
+		require.NoError(t, err)
+		require.Equal(t, []int{3, 3, 3}, result)
+	})
+}
+
+// blockingExecutor runs functions on a single worker, Go blocks until the worker takes fn
+type blockingExecutor struct {
+	fns chan func()
+}
+
+func newBlockingExecutor() *blockingExecutor {
+	e := &blockingExecutor{fns: make(chan func())}
+	go func() {
+		for fn := range e.fns {
+			fn()
+		}
+	}()
+	return e
+}
+
+func (e *blockingExecutor) Go(fn func()) {
+	e.fns <- fn
+}
+
+func TestInlineExecutor(t *testing.T) {
+
+	wantedErr := errors.New("wanted")
+
+	newWaiter := func(e Executor) (Waiter[int], *[]int) {
+		var started []int
+		w := New[int]().WithOptions(WithExecutor(e))
+		for i := 0; i < 5; i++ {
+			i := i
+			w.Add(func(ctx context.Context) (int, error) {
+				started = append(started, i)
+				if i%2 == 0 {
+					return 0, wantedErr
+				}
+				return i, nil
+			})
+		}
+		return w, &started
+	}
+
+	t.Run("declared_should_work", func(t *testing.T) {
+		w, started := newWaiter(InlineExecutor{})
+
+		result, errs, err := w.Wait(context.Background())
+		require.ErrorIs(t, err, ErrTooLessDone)
+		require.Equal(t, []int{1, 3}, result)
+		require.Len(t, errs, 3)
+		require.Equal(t, []int{0, 1, 2, 3, 4}, *started)
+	})
+
+	t.Run("reversed_should_work", func(t *testing.T) {
+		w, started := newWaiter(InlineExecutor{Order: OrderReversed})
+
+		result, _, err := w.WaitAny(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, 3, result)
+		// the rest are never started
+		require.Equal(t, []int{4, 3}, *started)
+	})
+
+	t.Run("shuffled_should_be_reproduced_by_seed", func(t *testing.T) {
+		run := func(seed int64) []int {
+			w, started := newWaiter(InlineExecutor{Order: OrderShuffled, Seed: seed})
+			_, _, _ = w.Wait(context.Background())
+			return *started
+		}
+
+		first := run(42)
+		require.ElementsMatch(t, []int{0, 1, 2, 3, 4}, first)
+		for i := 0; i < 3; i++ {
+			require.Equal(t, first, run(42))
+		}
+		require.NotEqual(t, first, run(7))
+	})
+
+	t.Run("dynamic_should_work", func(t *testing.T) {
+		var started []string
+
+		a := NewA().WithOptions(WithDynamic(), WithExecutor(InlineExecutor{}))
+		a.AddNamed("parent", func(ctx context.Context) error {
+			started = append(started, "parent")
+			a.AddNamed("child", func(ctx context.Context) error {
+				started = append(started, "child")
+				return nil
+			})
+			return nil
+		})
+		a.AddNamed("sibling", func(ctx context.Context) error {
+			started = append(started, "sibling")
+			return nil
+		})
+
+		_, err := a.Wait(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, []string{"parent", "sibling", "child"}, started)
+	})
+
+	t.Run("memoize_should_work", func(t *testing.T) {
+		w, started := newWaiter(InlineExecutor{})
+		w.WithOptions(WithMemoize())
+
+		for i := 0; i < 2; i++ {
+			result, _, err := w.WaitN(context.Background(), 2)
+			require.NoError(t, err)
+			require.Equal(t, []int{1, 3}, result)
+		}
+		require.Equal(t, []int{0, 1, 2, 3}, *started)
+	})
+}
+
+func benchmarkWait(b *testing.B, e Executor) {
+	task := func(ctx context.Context) (int, error) {
+		return 1, nil
+	}
+
+	w := New[int]()
+	for i := 0; i < 100; i++ {
+		w.Add(task)
+	}
+	if e != nil {
+		w.WithOptions(WithExecutor(e))
+	}
+
+	ctx := context.Background()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := w.Wait(ctx); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkWait compare the executors with a baseline that runs the same tasks on goroutines without Waiter.
+// WorkerExecutor runs each task on its workers with a single allocation, without a closure or a goroutine.
+func BenchmarkWait(b *testing.B) {
+	b.Run("baseline", func(b *testing.B) {
+		task := func(ctx context.Context) (int, error) {
+			return 1, nil
+		}
+
+		ctx := context.Background()
+
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			results := make(chan Result[int], 100)
+			for j := 0; j < 100; j++ {
+				go func(j int) {
+					v, err := task(ctx)
+					results <- Result[int]{Index: j, Data: v, Error: err}
+				}(j)
+			}
+
+			items := make([]int, 0, 100)
+			for j := 0; j < 100; j++ {
+				r := <-results
+				if r.Error != nil {
+					b.Fatal(r.Error)
+				}
+				items = append(items, r.Data)
+			}
+		}
+	})
+
+	b.Run("go", func(b *testing.B) {
+		benchmarkWait(b, nil)
+	})
+
+	b.Run("worker", func(b *testing.B) {
+		e := NewWorkerExecutor(runtime.GOMAXPROCS(0), 128)
+		defer e.Close()
+
+		benchmarkWait(b, e)
+	})
+}
@@ -0,0 +1,107 @@
+package async
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+var statusColors = map[NodeStatus]string{
+	NodePending:   "#ffffff",
+	NodeSucceeded: "#c8e6c9",
+	NodeFailed:    "#ffcdd2",
+	NodeSkipped:   "#eeeeee",
+	NodeCanceled:  "#fff9c4",
+}
+
+// DOT render the DAG as Graphviz DOT. If r is not nil, the status and elapsed time of each node in r are rendered too.
+func (d *DAG[T]) DOT(r *Report[T]) string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var sb strings.Builder
+	sb.WriteString("digraph {\n")
+	sb.WriteString("  node [shape=box, style=\"rounded,filled\", fillcolor=\"#ffffff\"];\n")
+
+	for _, n := range d.nodes {
+		label := n.name
+		attrs := ""
+		if nr, ok := lookup(r, n.name); ok {
+			label += "\n" + describe(nr)
+			attrs = fmt.Sprintf(", fillcolor=%q", statusColors[nr.Status])
+		}
+		fmt.Fprintf(&sb, "  %s [label=%s%s];\n", quoteDOT(n.name), quoteDOT(label), attrs)
+	}
+
+	for _, n := range d.nodes {
+		for _, dep := range n.deps {
+			fmt.Fprintf(&sb, "  %s -> %s;\n", quoteDOT(dep), quoteDOT(n.name))
+		}
+	}
+
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+// Mermaid render the DAG as Mermaid flowchart. If r is not nil, the status and elapsed time of each node in r are rendered too.
+func (d *DAG[T]) Mermaid(r *Report[T]) string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var sb strings.Builder
+	sb.WriteString("flowchart TD\n")
+
+	used := make(map[NodeStatus]bool)
+	for i, n := range d.nodes {
+		label := n.name
+		class := ""
+		if nr, ok := lookup(r, n.name); ok {
+			label += "<br/>" + describe(nr)
+			class = ":::" + nr.Status.String()
+			used[nr.Status] = true
+		}
+		fmt.Fprintf(&sb, "  n%d[\"%s\"]%s\n", i, quoteMermaid(label), class)
+	}
+
+	for i, n := range d.nodes {
+		for _, dep := range n.deps {
+			if p, ok := d.index[dep]; ok {
+				fmt.Fprintf(&sb, "  n%d --> n%d\n", p, i)
+			}
+		}
+	}
+
+	for s := NodePending; s <= NodeCanceled; s++ {
+		if used[s] {
+			fmt.Fprintf(&sb, "  classDef %s fill:%s\n", s, statusColors[s])
+		}
+	}
+
+	return sb.String()
+}
+
+func lookup[T any](r *Report[T], name string) (NodeReport[T], bool) {
+	if r == nil {
+		return NodeReport[T]{}, false
+	}
+	return r.Node(name)
+}
+
+// describe get the status and elapsed time of a node
+func describe[T any](nr NodeReport[T]) string {
+	if nr.Started.IsZero() {
+		return nr.Status.String()
+	}
+	return nr.Status.String() + " " + nr.Elapsed.Round(time.Microsecond).String()
+}
+
+func quoteDOT(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	return `"` + s + `"`
+}
+
+func quoteMermaid(s string) string {
+	return strings.ReplaceAll(s, `"`, "#quot;")
+}
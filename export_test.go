@@ -0,0 +1,80 @@
+package async
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExport(t *testing.T) {
+
+	task := func(ctx context.Context) (int, error) {
+		return 0, nil
+	}
+
+	d := NewDAG[int]().
+		Add("a", task).
+		Add(`b "1"`, task).
+		Add("c", task, "a", `b "1"`)
+
+	started := time.Now()
+	r := &Report[int]{
+		Nodes: []NodeReport[int]{
+			{Name: "a", Status: NodeSucceeded, Started: started, Elapsed: 12 * time.Millisecond},
+			{Name: `b "1"`, Status: NodeFailed, Started: started, Elapsed: 3 * time.Millisecond},
+			{Name: "c", Status: NodeSkipped},
+		},
+	}
+
+	t.Run("dot_should_work", func(t *testing.T) {
+		require.Equal(t, `digraph {
+  node [shape=box, style="rounded,filled", fillcolor="#ffffff"];
+  "a" [label="a"];
+  "b \"1\"" [label="b \"1\""];
+  "c" [label="c"];
+  "a" -> "c";
+  "b \"1\"" -> "c";
+}
+`, d.DOT(nil))
+
+		require.Equal(t, `digraph {
+  node [shape=box, style="rounded,filled", fillcolor="#ffffff"];
+  "a" [label="a\nsucceeded 12ms", fillcolor="#c8e6c9"];
+  "b \"1\"" [label="b \"1\"\nfailed 3ms", fillcolor="#ffcdd2"];
+  "c" [label="c\nskipped", fillcolor="#eeeeee"];
+  "a" -> "c";
+  "b \"1\"" -> "c";
+}
+`, d.DOT(r))
+	})
+
+	t.Run("mermaid_should_work", func(t *testing.T) {
+		require.Equal(t, `flowchart TD
+  n0["a"]
+  n1["b #quot;1#quot;"]
+  n2["c"]
+  n0 --> n2
+  n1 --> n2
+`, d.Mermaid(nil))
+
+		require.Equal(t, `flowchart TD
+  n0["a<br/>succeeded 12ms"]:::succeeded
+  n1["b #quot;1#quot;<br/>failed 3ms"]:::failed
+  n2["c<br/>skipped"]:::skipped
+  n0 --> n2
+  n1 --> n2
+  classDef succeeded fill:#c8e6c9
+  classDef failed fill:#ffcdd2
+  classDef skipped fill:#eeeeee
+`, d.Mermaid(r))
+	})
+
+	t.Run("run_report_should_work", func(t *testing.T) {
+		r, err := d.Run(context.Background())
+		require.NoError(t, err)
+		require.Contains(t, d.DOT(r), `"c" [label="c\nsucceeded `)
+		require.Contains(t, d.Mermaid(r), `n2["c<br/>succeeded `)
+	})
+}
@@ -0,0 +1,135 @@
+package async
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrTypeMismatch is returned when the result that is shared by a key is not the type of the caller, e.g. the same key
+// is used by tasks of different types
+var ErrTypeMismatch = errors.New("async: result type mismatch")
+
+// call an in-flight call of Group that is shared by its callers
+type call struct {
+	done   chan struct{}
+	cancel context.CancelCauseFunc
+	val    any
+	err    error
+	// callers is the number of callers that are waiting for the call, and shared is true once a second one joins
+	callers int
+	shared  bool
+}
+
+// Group dedupes concurrent calls with the same key, so they share a single in-flight execution and its result, see
+// Dedupe and WithGroup. The results are not cached once calls are completed.
+type Group struct {
+	mu    sync.Mutex
+	calls map[string]*call
+}
+
+// NewGroup create an empty Group
+func NewGroup() *Group {
+	return &Group{
+		calls: make(map[string]*call),
+	}
+}
+
+// Do call fn with key, or join the in-flight call with the same key and share its result. shared is true if the result
+// is shared with other callers.
+//
+// fn runs on a context that carries the values of the first caller's ctx, but it is not canceled by any single caller.
+// A caller returns the cause of its ctx once ctx is canceled, and fn is canceled only when all callers have left.
+// The panic of fn is recovered and returned to all callers as an error, which wraps ErrPanic.
+func (g *Group) Do(ctx context.Context, key string, fn func(ctx context.Context) (any, error)) (v any, shared bool, err error) {
+	g.mu.Lock()
+	c, ok := g.calls[key]
+	if ok {
+		c.callers++
+		c.shared = true
+	} else {
+		c = &call{done: make(chan struct{}), callers: 1}
+
+		var callCtx context.Context
+		callCtx, c.cancel = context.WithCancelCause(context.WithoutCancel(ctx))
+		g.calls[key] = c
+
+		go g.do(callCtx, key, c, fn)
+	}
+	g.mu.Unlock()
+
+	select {
+	case <-c.done:
+		return c.val, c.shared, c.err
+	case <-ctx.Done():
+		g.leave(key, c, context.Cause(ctx))
+		return nil, false, context.Cause(ctx)
+	}
+}
+
+func (g *Group) do(ctx context.Context, key string, c *call, fn func(ctx context.Context) (any, error)) {
+	v, err := try(ctx, fn)
+
+	g.mu.Lock()
+	c.val, c.err = v, err
+	if g.calls[key] == c {
+		delete(g.calls, key)
+	}
+	g.mu.Unlock()
+
+	close(c.done)
+	c.cancel(nil)
+}
+
+// leave remove a caller from c, and cancel c with cause once all callers have left
+func (g *Group) leave(key string, c *call, cause error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	c.callers--
+	if c.callers > 0 {
+		return
+	}
+
+	// the following callers with key start a new call
+	if g.calls[key] == c {
+		delete(g.calls, key)
+	}
+	c.cancel(cause)
+}
+
+// Dedupe share the in-flight execution of task by key in g, so concurrent calls with the same key run task only once.
+// ErrTypeMismatch is returned if the shared result is not T.
+func Dedupe[T any](task Task[T], g *Group, key string) Task[T] {
+	return func(ctx context.Context) (T, error) {
+		v, _, err := g.Do(ctx, key, func(ctx context.Context) (any, error) {
+			return task(ctx)
+		})
+
+		t, terr := as[T](v, key)
+		if err == nil {
+			err = terr
+		}
+		return t, err
+	}
+}
+
+// as get v as T, ErrTypeMismatch is returned if v is not T. nil is the zero value of T.
+func as[T any](v any, key string) (T, error) {
+	t, ok := v.(T)
+	if !ok && v != nil {
+		return t, fmt.Errorf("%w: %T is shared by key %q", ErrTypeMismatch, v, key)
+	}
+	return t, nil
+}
+
+// DedupeA share the in-flight execution of action by key in g, so concurrent calls with the same key run action only once
+func DedupeA(action Action, g *Group, key string) Action {
+	return func(ctx context.Context) error {
+		_, _, err := g.Do(ctx, key, func(ctx context.Context) (any, error) {
+			return nil, action(ctx)
+		})
+		return err
+	}
+}
@@ -0,0 +1,208 @@
+package async
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGroup(t *testing.T) {
+
+	wantedErr := errors.New("wanted")
+
+	t.Run("concurrent_calls_should_be_shared", func(t *testing.T) {
+		g := NewGroup()
+
+		var calls atomic.Int32
+		release := make(chan struct{})
+		task := Dedupe(func(ctx context.Context) (int, error) {
+			calls.Add(1)
+			<-release
+			return 1, wantedErr
+		}, g, "key")
+
+		var wg sync.WaitGroup
+		results := make([]int, 5)
+		errs := make([]error, 5)
+		for i := 0; i < 5; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				results[i], errs[i] = task(context.Background())
+			}(i)
+		}
+
+		time.Sleep(20 * time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		require.Equal(t, int32(1), calls.Load())
+		require.Equal(t, []int{1, 1, 1, 1, 1}, results)
+		for _, err := range errs {
+			require.ErrorIs(t, err, wantedErr)
+		}
+
+		// results are not cached
+		_, _ = task(context.Background())
+		require.Equal(t, int32(2), calls.Load())
+	})
+
+	t.Run("shared_call_should_be_canceled_once_all_callers_left", func(t *testing.T) {
+		g := NewGroup()
+
+		started := make(chan struct{})
+		canceled := make(chan error, 1)
+		fn := func(ctx context.Context) (any, error) {
+			close(started)
+			<-ctx.Done()
+			canceled <- context.Cause(ctx)
+			return nil, ctx.Err()
+		}
+
+		ctx1, cancel1 := context.WithCancelCause(context.Background())
+		ctx2, cancel2 := context.WithCancelCause(context.Background())
+
+		errs := make(chan error, 2)
+		go func() {
+			_, _, err := g.Do(ctx1, "key", fn)
+			errs <- err
+		}()
+		<-started
+		go func() {
+			_, _, err := g.Do(ctx2, "key", fn)
+			errs <- err
+		}()
+		time.Sleep(10 * time.Millisecond)
+
+		cancel1(wantedErr)
+		require.ErrorIs(t, <-errs, wantedErr)
+
+		select {
+		case <-canceled:
+			require.Fail(t, "shared call should not be canceled by one caller")
+		case <-time.After(20 * time.Millisecond):
+		}
+
+		cancel2(ErrSiblingFailed)
+		require.ErrorIs(t, <-errs, ErrSiblingFailed)
+		require.ErrorIs(t, <-canceled, ErrSiblingFailed)
+	})
+
+	t.Run("shared_should_be_reported", func(t *testing.T) {
+		g := NewGroup()
+
+		release := make(chan struct{})
+		fn := func(ctx context.Context) (any, error) {
+			<-release
+			return "v", nil
+		}
+
+		shared := make(chan bool, 2)
+		for i := 0; i < 2; i++ {
+			go func() {
+				_, s, _ := g.Do(context.Background(), "key", fn)
+				shared <- s
+			}()
+		}
+		time.Sleep(10 * time.Millisecond)
+		close(release)
+
+		require.True(t, <-shared)
+		require.True(t, <-shared)
+
+		v, s, err := g.Do(context.Background(), "key", fn)
+		require.NoError(t, err)
+		require.False(t, s)
+		require.Equal(t, "v", v)
+	})
+
+	t.Run("waiters_should_dedupe_named_tasks", func(t *testing.T) {
+		g := NewGroup()
+
+		var calls atomic.Int32
+		task := func(ctx context.Context) (int, error) {
+			calls.Add(1)
+			time.Sleep(20 * time.Millisecond)
+			return 1, nil
+		}
+
+		newWaiter := func() Waiter[int] {
+			w := New[int]().WithOptions(WithGroup(g))
+			w.AddNamed("user:1", task)
+			w.AddNamed("user:1", task)
+			w.Add(task)
+			return w
+		}
+
+		var wg sync.WaitGroup
+		for i := 0; i < 2; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				result, _, err := newWaiter().Wait(context.Background())
+				require.NoError(t, err)
+				require.Equal(t, []int{1, 1, 1}, result)
+			}()
+		}
+		wg.Wait()
+
+		// one shared execution of "user:1", and an unnamed task in each waiter
+		require.Equal(t, int32(3), calls.Load())
+	})
+
+	t.Run("awaiter_should_work", func(t *testing.T) {
+		g := NewGroup()
+
+		var calls atomic.Int32
+		action := DedupeA(func(ctx context.Context) error {
+			calls.Add(1)
+			time.Sleep(20 * time.Millisecond)
+			return nil
+		}, g, "key")
+
+		_, err := NewA(action, action, action).Wait(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, int32(1), calls.Load())
+	})
+
+	t.Run("type_mismatch_should_be_returned", func(t *testing.T) {
+		g := NewGroup()
+
+		started := make(chan struct{})
+		release := make(chan struct{})
+		ints := make(chan int, 1)
+		go func() {
+			v, _ := Dedupe(func(ctx context.Context) (int, error) {
+				close(started)
+				<-release
+				return 1, nil
+			}, g, "key")(context.Background())
+			ints <- v
+		}()
+		<-started
+
+		errs := make(chan error, 1)
+		go func() {
+			_, err := Dedupe(func(ctx context.Context) (string, error) {
+				return "1", nil
+			}, g, "key")(context.Background())
+			errs <- err
+		}()
+
+		// the string call joins the int call
+		require.Eventually(t, func() bool {
+			g.mu.Lock()
+			defer g.mu.Unlock()
+			return g.calls["key"].shared
+		}, time.Second, time.Millisecond)
+		close(release)
+
+		require.Equal(t, 1, <-ints)
+		require.ErrorIs(t, <-errs, ErrTypeMismatch)
+	})
+}
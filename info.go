@@ -0,0 +1,45 @@
+package async
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+var lastWaiterID atomic.Uint64
+
+// TaskInfo the metadata of a task/action that is started by Waiter/Awaiter
+type TaskInfo struct {
+	// WaiterID is the unique id of the Waiter/Awaiter in current process
+	WaiterID uint64
+	// Index is the position of the task/action in the Waiter/Awaiter
+	Index int
+	// Name is the name of the task/action. It is empty if it is added by Add
+	Name string
+	// Attempt is the 1-based number of times that the task/action has been started
+	Attempt int
+}
+
+type infoKey struct{}
+
+// InfoFrom get TaskInfo from the context that is passed to the task/action
+func InfoFrom(ctx context.Context) (TaskInfo, bool) {
+	info, ok := ctx.Value(infoKey{}).(TaskInfo)
+	return info, ok
+}
+
+// infoCtx carries TaskInfo in one allocation, instead of context.WithValue and a boxed TaskInfo
+type infoCtx struct {
+	context.Context
+	info TaskInfo
+}
+
+func (c *infoCtx) Value(key any) any {
+	if key == (infoKey{}) {
+		return c.info
+	}
+	return c.Context.Value(key)
+}
+
+func withInfo(ctx context.Context, info TaskInfo) context.Context {
+	return &infoCtx{Context: ctx, info: info}
+}
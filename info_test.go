@@ -0,0 +1,83 @@
+package async
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInfoFrom(t *testing.T) {
+
+	t.Run("task_should_work", func(t *testing.T) {
+		var mu sync.Mutex
+		infos := make(map[int]TaskInfo)
+
+		task := func(ctx context.Context) (int, error) {
+			info, ok := InfoFrom(ctx)
+			require.True(t, ok)
+
+			mu.Lock()
+			infos[info.Index] = info
+			mu.Unlock()
+			return info.Index, nil
+		}
+
+		a := New[int](task)
+		a.AddNamed("named", task)
+
+		_, _, err := a.Wait(context.Background())
+		require.NoError(t, err)
+		_, _, err = a.Wait(context.Background())
+		require.NoError(t, err)
+
+		id := infos[0].WaiterID
+		require.NotZero(t, id)
+		require.Equal(t, TaskInfo{WaiterID: id, Index: 0, Attempt: 2}, infos[0])
+		require.Equal(t, TaskInfo{WaiterID: id, Index: 1, Name: "named", Attempt: 2}, infos[1])
+	})
+
+	t.Run("action_should_work", func(t *testing.T) {
+		var info TaskInfo
+		a := NewA()
+		a.AddNamed("named", func(ctx context.Context) error {
+			info, _ = InfoFrom(ctx)
+			return nil
+		})
+
+		_, err := a.WaitAny(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, "named", info.Name)
+		require.Equal(t, 1, info.Attempt)
+		require.NotEqual(t, New[int]().(*waiter[int]).id, info.WaiterID)
+	})
+
+	t.Run("missing_should_work", func(t *testing.T) {
+		_, ok := InfoFrom(context.Background())
+		require.False(t, ok)
+	})
+}
+
+var infoSink context.Context
+
+// BenchmarkWithInfo compare the cost of carrying TaskInfo for each task by withInfo and by context.WithValue that boxes
+// it, the tasks that don't call InfoFrom don't pay more
+func BenchmarkWithInfo(b *testing.B) {
+	ctx := context.Background()
+	info := TaskInfo{WaiterID: 1, Index: 2, Name: "task", Attempt: 1}
+
+	b.Run("value", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			infoSink = context.WithValue(ctx, infoKey{}, info)
+		}
+	})
+
+	b.Run("info", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			infoSink = withInfo(ctx, info)
+		}
+	})
+}
@@ -0,0 +1,161 @@
+package async
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// ErrUnnamedTask is returned for a task/action without name when a Journal is set, because it has no stable ID to be
+// recorded, see WithJournal and AddNamed.
+var ErrUnnamedTask = errors.New("async: unnamed task/action can't be recorded in journal")
+
+// Codec encodes and decodes the results in Journal
+type Codec interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+// JSONCodec encodes results as JSON
+type JSONCodec struct{}
+
+func (JSONCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (JSONCodec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+// record a line in journal file
+type record struct {
+	Key  string `json:"k"`
+	Data []byte `json:"d"`
+}
+
+// Journal a local append-only file that records the results of completed tasks by their names, so a Waiter can skip them
+// when it is run again after the process is restarted, see WithJournal. Records are written to the file once tasks are
+// completed, so they survive process crashes; call Sync to survive system crashes too.
+type Journal struct {
+	codec Codec
+
+	mu      sync.Mutex
+	f       *os.File
+	records map[string][]byte
+}
+
+// OpenJournal open the journal file at path, it is created if it doesn't exist. A torn record at the end of file, that
+// is written partially when the process crashes, is truncated. Results are encoded by codec, JSONCodec if it is nil.
+func OpenJournal(path string, codec Codec) (*Journal, error) {
+	if codec == nil {
+		codec = JSONCodec{}
+	}
+
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	j := &Journal{
+		codec:   codec,
+		f:       f,
+		records: make(map[string][]byte),
+	}
+
+	if err := j.load(); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return j, nil
+}
+
+// load read all records, and truncate the torn record at the end of file
+func (j *Journal) load() error {
+	r := bufio.NewReader(j.f)
+
+	var size int64
+	for {
+		line, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		var rec record
+		if err := json.Unmarshal(bytes.TrimSpace(line), &rec); err != nil {
+			return fmt.Errorf("async: journal is corrupted at offset %d: %w", size, err)
+		}
+
+		j.records[rec.Key] = rec.Data
+		size += int64(len(line))
+	}
+
+	if err := j.f.Truncate(size); err != nil {
+		return err
+	}
+
+	_, err := j.f.Seek(size, io.SeekStart)
+	return err
+}
+
+// Get decode the result of key into v, it returns false if key is not recorded
+func (j *Journal) Get(key string, v any) (bool, error) {
+	j.mu.Lock()
+	data, ok := j.records[key]
+	j.mu.Unlock()
+
+	if !ok {
+		return false, nil
+	}
+
+	return true, j.codec.Unmarshal(data, v)
+}
+
+// Put encode v and record it with key
+func (j *Journal) Put(key string, v any) error {
+	data, err := j.codec.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	line, err := json.Marshal(record{Key: key, Data: data})
+	if err != nil {
+		return err
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if _, err := j.f.Write(append(line, '\n')); err != nil {
+		return err
+	}
+
+	j.records[key] = data
+	return nil
+}
+
+// Len get the number of recorded keys
+func (j *Journal) Len() int {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return len(j.records)
+}
+
+// Sync commit the records to stable storage
+func (j *Journal) Sync() error {
+	return j.f.Sync()
+}
+
+// Close close the journal file
+func (j *Journal) Close() error {
+	return j.f.Close()
+}
@@ -0,0 +1,160 @@
+package async
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestJournal(t *testing.T) {
+
+	wantedErr := errors.New("wanted")
+
+	t.Run("resume_should_skip_recorded_tasks", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "journal")
+
+		var calls atomic.Int32
+		var failing atomic.Bool
+		failing.Store(true)
+
+		newTasks := func() Waiter[int] {
+			w := New[int]()
+			w.AddNamed("a", func(ctx context.Context) (int, error) {
+				calls.Add(1)
+				return 1, nil
+			})
+			w.AddNamed("b", func(ctx context.Context) (int, error) {
+				calls.Add(1)
+				if failing.Load() {
+					return 0, wantedErr
+				}
+				return 2, nil
+			})
+			w.AddNamed("c", func(ctx context.Context) (int, error) {
+				calls.Add(1)
+				return 3, nil
+			})
+			return w
+		}
+
+		j, err := OpenJournal(path, nil)
+		require.NoError(t, err)
+
+		result, taskErrs, err := newTasks().WithOptions(WithJournal(j)).Wait(context.Background())
+		require.ErrorIs(t, err, ErrTooLessDone)
+		require.ElementsMatch(t, []int{1, 3}, result)
+		require.Equal(t, []error{wantedErr}, taskErrs)
+		require.Equal(t, int32(3), calls.Load())
+		require.NoError(t, j.Close())
+
+		// reopen as if the process is restarted
+		j, err = OpenJournal(path, JSONCodec{})
+		require.NoError(t, err)
+		defer j.Close()
+		require.Equal(t, 2, j.Len())
+
+		failing.Store(false)
+		result, taskErrs, err = newTasks().WithOptions(WithJournal(j)).Wait(context.Background())
+		require.NoError(t, err)
+		require.Empty(t, taskErrs)
+		require.ElementsMatch(t, []int{1, 2, 3}, result)
+		require.Equal(t, int32(4), calls.Load())
+		require.Equal(t, 3, j.Len())
+	})
+
+	t.Run("awaiter_should_work", func(t *testing.T) {
+		j, err := OpenJournal(filepath.Join(t.TempDir(), "journal"), nil)
+		require.NoError(t, err)
+		defer j.Close()
+
+		var calls atomic.Int32
+		a := NewA().WithOptions(WithJournal(j))
+		a.AddNamed("a", func(ctx context.Context) error {
+			calls.Add(1)
+			return nil
+		})
+
+		for i := 0; i < 2; i++ {
+			_, err = a.Wait(context.Background())
+			require.NoError(t, err)
+		}
+		require.Equal(t, int32(1), calls.Load())
+	})
+
+	t.Run("unnamed_should_fail", func(t *testing.T) {
+		j, err := OpenJournal(filepath.Join(t.TempDir(), "journal"), nil)
+		require.NoError(t, err)
+		defer j.Close()
+
+		var calls atomic.Int32
+		w := New[int](func(ctx context.Context) (int, error) {
+			calls.Add(1)
+			return 100, nil
+		}).WithOptions(WithJournal(j))
+		w.AddNamed("a", func(ctx context.Context) (int, error) {
+			return 0, wantedErr
+		})
+
+		result, taskErrs, err := w.Wait(context.Background())
+		require.ErrorIs(t, err, ErrTooLessDone)
+		require.Empty(t, result)
+		require.ElementsMatch(t, []error{ErrUnnamedTask, wantedErr}, taskErrs)
+		require.Equal(t, int32(0), calls.Load())
+		require.Equal(t, 0, j.Len())
+
+		// the failed task isn't replaced by the record of another one after the tasks are shifted
+		require.True(t, w.Remove(0))
+		result, taskErrs, err = w.Wait(context.Background())
+		require.ErrorIs(t, err, ErrTooLessDone)
+		require.Empty(t, result)
+		require.Equal(t, []error{wantedErr}, taskErrs)
+	})
+
+	t.Run("torn_record_should_be_truncated", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "journal")
+
+		j, err := OpenJournal(path, nil)
+		require.NoError(t, err)
+		require.NoError(t, j.Put("a", "x"))
+		require.NoError(t, j.Close())
+
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+		require.NoError(t, err)
+		_, err = f.WriteString(`{"k":"b","d":`)
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+
+		j, err = OpenJournal(path, nil)
+		require.NoError(t, err)
+		require.Equal(t, 1, j.Len())
+		require.NoError(t, j.Put("b", "y"))
+		require.NoError(t, j.Close())
+
+		j, err = OpenJournal(path, nil)
+		require.NoError(t, err)
+		defer j.Close()
+
+		var v string
+		ok, err := j.Get("b", &v)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, "y", v)
+
+		ok, err = j.Get("c", &v)
+		require.NoError(t, err)
+		require.False(t, ok)
+	})
+
+	t.Run("corrupted_record_should_fail", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "journal")
+		require.NoError(t, os.WriteFile(path, []byte("oops\n"), 0o644))
+
+		_, err := OpenJournal(path, nil)
+		require.Error(t, err)
+	})
+}
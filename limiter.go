@@ -0,0 +1,148 @@
+package async
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// ConcurrencyLimiter limits how many tasks/actions run concurrently, see WithConcurrency
+type ConcurrencyLimiter interface {
+	// Acquire wait for a slot, and return the function to release it with the error of task/action. The cause of ctx
+	// is returned if it is canceled before that.
+	Acquire(ctx context.Context) (release func(err error), err error)
+	// Limit get current limit
+	Limit() int
+	// Inflight get the number of running tasks/actions
+	Inflight() int
+}
+
+// AdaptiveConfig the config of an adaptive limiter, zero values are replaced by defaults
+type AdaptiveConfig struct {
+	// Initial is the initial limit, it is Min by default
+	Initial int
+	// Min is the min limit, 1 by default
+	Min int
+	// Max is the max limit, 1000 by default
+	Max int
+	// Latency is the max latency of a healthy task/action. The limit is decreased on slower ones, and latency is
+	// ignored if it is zero.
+	Latency time.Duration
+	// Backoff is the ratio that the limit is multiplied by on a failure or slow task/action, 0.9 by default
+	Backoff float64
+}
+
+// limiter limits concurrency by limit that is fixed or adjusted by AIMD
+type limiter struct {
+	cfg      AdaptiveConfig
+	adaptive bool
+
+	mu       sync.Mutex
+	limit    float64
+	inflight int
+	// released is closed and replaced once a slot is released, to wake up the waiting ones
+	released chan struct{}
+}
+
+// NewFixedLimiter create a ConcurrencyLimiter that runs at most n tasks/actions at once
+func NewFixedLimiter(n int) ConcurrencyLimiter {
+	if n < 1 {
+		n = 1
+	}
+
+	return &limiter{
+		limit:    float64(n),
+		released: make(chan struct{}),
+	}
+}
+
+// NewAdaptiveLimiter create a ConcurrencyLimiter that adjusts its limit by additive-increase/multiplicative-decrease.
+// The limit is increased by 1/limit on each fast success, so it is increased by about 1 once all slots are succeeded;
+// and it is multiplied by Backoff on each failure or slow one. The errors when context is canceled are ignored.
+func NewAdaptiveLimiter(cfg AdaptiveConfig) ConcurrencyLimiter {
+	if cfg.Min < 1 {
+		cfg.Min = 1
+	}
+	if cfg.Max < 1 {
+		cfg.Max = 1000
+	}
+	if cfg.Max < cfg.Min {
+		cfg.Max = cfg.Min
+	}
+	if cfg.Initial < cfg.Min {
+		cfg.Initial = cfg.Min
+	}
+	if cfg.Initial > cfg.Max {
+		cfg.Initial = cfg.Max
+	}
+	if cfg.Backoff <= 0 || cfg.Backoff >= 1 {
+		cfg.Backoff = 0.9
+	}
+
+	return &limiter{
+		cfg:      cfg,
+		adaptive: true,
+		limit:    float64(cfg.Initial),
+		released: make(chan struct{}),
+	}
+}
+
+func (l *limiter) Acquire(ctx context.Context) (func(err error), error) {
+	for {
+		l.mu.Lock()
+		if l.inflight < int(l.limit) {
+			l.inflight++
+			l.mu.Unlock()
+
+			clock := ClockFrom(ctx)
+			started := clock.Now()
+			return func(err error) {
+				l.release(err, clock.Now().Sub(started), err != nil && ctx.Err() != nil)
+			}, nil
+		}
+		released := l.released
+		l.mu.Unlock()
+
+		select {
+		case <-released:
+		case <-ctx.Done():
+			return nil, context.Cause(ctx)
+		}
+	}
+}
+
+// release release a slot, and adjust the limit by err and latency unless the task/action is canceled
+func (l *limiter) release(err error, latency time.Duration, canceled bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.inflight--
+	close(l.released)
+	l.released = make(chan struct{})
+
+	if !l.adaptive || canceled {
+		return
+	}
+
+	if err != nil || (l.cfg.Latency > 0 && latency > l.cfg.Latency) {
+		l.limit = math.Max(float64(l.cfg.Min), l.limit*l.cfg.Backoff)
+		return
+	}
+
+	l.limit = math.Min(float64(l.cfg.Max), l.limit+1/l.limit)
+}
+
+func (l *limiter) Limit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return int(l.limit)
+}
+
+func (l *limiter) Inflight() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.inflight
+}
@@ -0,0 +1,126 @@
+package async
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConcurrencyLimiter(t *testing.T) {
+
+	wantedErr := errors.New("wanted")
+
+	t.Run("fixed_should_work", func(t *testing.T) {
+		l := NewFixedLimiter(2)
+
+		var running, peak atomic.Int32
+		task := func(ctx context.Context) (int, error) {
+			if r := running.Add(1); r > peak.Load() {
+				peak.Store(r)
+			}
+			time.Sleep(5 * time.Millisecond)
+			running.Add(-1)
+			return 1, nil
+		}
+
+		a := New[int](task, task, task, task, task).WithOptions(WithConcurrency(l))
+		result, _, err := a.Wait(context.Background())
+		require.NoError(t, err)
+		require.Len(t, result, 5)
+		require.Equal(t, int32(2), peak.Load())
+		require.Equal(t, 2, l.Limit())
+		require.Zero(t, l.Inflight())
+	})
+
+	t.Run("adaptive_should_work", func(t *testing.T) {
+		l := NewAdaptiveLimiter(AdaptiveConfig{
+			Initial: 2,
+			Min:     2,
+			Max:     4,
+			Latency: time.Second,
+			Backoff: 0.5,
+		})
+
+		ctx := context.Background()
+		acquire := func() func(error) {
+			release, err := l.Acquire(ctx)
+			require.NoError(t, err)
+			return release
+		}
+
+		// additive increase: 2, 2.5, 2.9, 3.24, 3.55, 3.83, 4.09
+		for i := 0; i < 5; i++ {
+			acquire()(nil)
+		}
+		require.Equal(t, 3, l.Limit())
+
+		for i := 0; i < 2; i++ {
+			acquire()(nil)
+		}
+		require.Equal(t, 4, l.Limit())
+
+		for i := 0; i < 10; i++ {
+			acquire()(nil)
+		}
+		require.Equal(t, 4, l.Limit())
+
+		r1, r2, r3 := acquire(), acquire(), acquire()
+		require.Equal(t, 3, l.Inflight())
+
+		// multiplicative decrease
+		r1(wantedErr)
+		require.Equal(t, 2, l.Limit())
+		r2(wantedErr)
+		require.Equal(t, 2, l.Limit())
+		r3(nil)
+		require.Zero(t, l.Inflight())
+	})
+
+	t.Run("latency_should_work", func(t *testing.T) {
+		l := NewAdaptiveLimiter(AdaptiveConfig{
+			Initial: 10,
+			Latency: time.Millisecond,
+			Backoff: 0.5,
+		})
+
+		release, err := l.Acquire(context.Background())
+		require.NoError(t, err)
+		time.Sleep(2 * time.Millisecond)
+		release(nil)
+		require.Equal(t, 5, l.Limit())
+	})
+
+	t.Run("canceled_should_be_ignored", func(t *testing.T) {
+		l := NewAdaptiveLimiter(AdaptiveConfig{Initial: 4})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		release, err := l.Acquire(ctx)
+		require.NoError(t, err)
+		cancel()
+		release(context.Canceled)
+		require.Equal(t, 4, l.Limit())
+	})
+
+	t.Run("acquire_should_wait", func(t *testing.T) {
+		l := NewFixedLimiter(1)
+		release, err := l.Acquire(context.Background())
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		_, err = l.Acquire(ctx)
+		require.Equal(t, context.DeadlineExceeded, err)
+
+		go func() {
+			time.Sleep(5 * time.Millisecond)
+			release(nil)
+		}()
+		release, err = l.Acquire(context.Background())
+		require.NoError(t, err)
+		release(nil)
+	})
+}
@@ -0,0 +1,35 @@
+package async
+
+import "time"
+
+// Observer the hooks that are called when tasks/actions are started and completed, e.g. for logging and metrics, see
+// WithObserver. Hooks are called on the goroutines of tasks/actions, so they should be fast and safe for concurrent use.
+// Nil hooks are ignored.
+type Observer struct {
+	// OnStart is called before a task/action is started
+	OnStart func(info TaskInfo)
+	// OnDone is called once a task/action is completed with its error and elapsed time
+	OnDone func(info TaskInfo, err error, elapsed time.Duration)
+	// OnHung is called once a task/action is not completed in the threshold of WithWatchdog, with the goroutine profile
+	// records of its goroutines, see runtime/pprof. stack is nil if they can't be found. It is called on the goroutine
+	// of watchdog while the task/action is still running.
+	OnHung func(info TaskInfo, elapsed time.Duration, stack []byte)
+}
+
+func (o *Observer) start(info TaskInfo) {
+	if o != nil && o.OnStart != nil {
+		o.OnStart(info)
+	}
+}
+
+func (o *Observer) done(info TaskInfo, err error, elapsed time.Duration) {
+	if o != nil && o.OnDone != nil {
+		o.OnDone(info, err, elapsed)
+	}
+}
+
+func (o *Observer) hung(info TaskInfo, elapsed time.Duration, stack []byte) {
+	if o != nil && o.OnHung != nil {
+		o.OnHung(info, elapsed, stack)
+	}
+}
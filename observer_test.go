@@ -0,0 +1,54 @@
+package async
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestObserver(t *testing.T) {
+
+	wantedErr := errors.New("wanted")
+
+	var mu sync.Mutex
+	started := make(map[string]int)
+	done := make(map[string]error)
+
+	o := Observer{
+		OnStart: func(info TaskInfo) {
+			mu.Lock()
+			defer mu.Unlock()
+			started[info.Name] = info.Attempt
+		},
+		OnDone: func(info TaskInfo, err error, elapsed time.Duration) {
+			mu.Lock()
+			defer mu.Unlock()
+			done[info.Name] = err
+			require.GreaterOrEqual(t, elapsed, time.Duration(0))
+		},
+	}
+
+	a := NewA().WithOptions(WithObserver(o))
+	a.AddNamed("ok", func(ctx context.Context) error {
+		return nil
+	})
+	a.AddNamed("failed", func(ctx context.Context) error {
+		return wantedErr
+	})
+
+	_, err := a.Wait(context.Background())
+	require.ErrorIs(t, err, ErrTooLessDone)
+
+	require.Equal(t, map[string]int{"ok": 1, "failed": 1}, started)
+	require.Equal(t, map[string]error{"ok": nil, "failed": wantedErr}, done)
+
+	// nil hooks are ignored
+	_, err = NewA(func(ctx context.Context) error {
+		return nil
+	}).WithOptions(WithObserver(Observer{})).Wait(context.Background())
+	require.NoError(t, err)
+}
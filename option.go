@@ -0,0 +1,128 @@
+package async
+
+import "time"
+
+// Option configures a Waiter/Awaiter, see WithOptions
+type Option func(o *options)
+
+type options struct {
+	progress    func(Progress)
+	errorPolicy ErrorPolicy
+	dynamic     bool
+	memoize     bool
+	rateLimit   *RateLimiter
+	concurrency ConcurrencyLimiter
+	journal     *Journal
+	group       *Group
+	observer    *Observer
+	executor    Executor
+	watchdog    time.Duration
+}
+
+// WithProgress report the progress of tasks/actions to fn, when any of them is completed or reports its own progress by ReportProgress
+func WithProgress(fn func(Progress)) Option {
+	return func(o *options) {
+		o.progress = fn
+	}
+}
+
+// WithDynamic start the tasks/actions that are added during a Wait/WaitAny/WaitN immediately, and wait for them
+// before it returns. So tasks/actions can add follow-up tasks/actions to the Waiter/Awaiter that they are running in.
+func WithDynamic() Option {
+	return func(o *options) {
+		o.dynamic = true
+	}
+}
+
+// WithMemoize cache the result of each task/action once it is completed, success or failure. So it is started only once,
+// and the following Wait/WaitAny/WaitN get its cached result, like a promise. The tasks/actions that are canceled
+// before they are completed, e.g. by WaitAny, are started again in the next one. See Reset.
+func WithMemoize() Option {
+	return func(o *options) {
+		o.memoize = true
+	}
+}
+
+// WithRateLimit delay the start of each task/action until l allows it. Its error is the cause of context if it is
+// canceled before that.
+func WithRateLimit(l *RateLimiter) Option {
+	return func(o *options) {
+		o.rateLimit = l
+	}
+}
+
+// WithConcurrency limit how many tasks/actions run concurrently by l, see NewFixedLimiter and NewAdaptiveLimiter.
+// It can be shared by multiple Waiters/Awaiters.
+func WithConcurrency(l ConcurrencyLimiter) Option {
+	return func(o *options) {
+		o.concurrency = l
+	}
+}
+
+// WithJournal record the result of each task/action in j once it is completed without error, and skip the ones that
+// are already recorded in j, their stored results are returned instead. So a Wait/WaitAny/WaitN that is interrupted,
+// e.g. by a crash, can be resumed by running the same Waiter/Awaiter again. Tasks/actions are identified by their names,
+// so their names must be unique among all Waiters/Awaiters that share j. The ones without names are not started, and
+// fail with ErrUnnamedTask, see AddNamed.
+func WithJournal(j *Journal) Option {
+	return func(o *options) {
+		o.journal = j
+	}
+}
+
+// WithGroup dedupe the named tasks/actions by their names in g, so the ones with the same name that are running
+// concurrently, e.g. in different Waiters/Awaiters, share a single execution and its result. See Dedupe.
+func WithGroup(g *Group) Option {
+	return func(o *options) {
+		o.group = g
+	}
+}
+
+// WithObserver call the hooks of o when tasks/actions are started and completed
+func WithObserver(o Observer) Option {
+	return func(opts *options) {
+		opts.observer = &o
+	}
+}
+
+// WithExecutor run tasks/actions by e instead of starting a new goroutine for each of them, see WorkerExecutor
+func WithExecutor(e Executor) Option {
+	return func(o *options) {
+		o.executor = e
+	}
+}
+
+// WithWatchdog report the tasks/actions that are not completed in threshold to Observer.OnHung with their stacks, e.g.
+// the ones that ignore their context. The goroutines of tasks/actions are labeled with "async.task" in pprof, whose
+// value is WaiterID/Index/Attempt of TaskInfo. See WithObserver.
+func WithWatchdog(threshold time.Duration) Option {
+	return func(o *options) {
+		o.watchdog = threshold
+	}
+}
+
+// ErrorPolicy the max number of failed tasks/actions that a Wait/WaitAny/WaitN can tolerate. Once it is reached,
+// ErrTooManyFailures is returned and the tasks/actions that are still running are canceled with ErrSiblingFailed.
+type ErrorPolicy int
+
+const (
+	// CollectAll never gives up on failures, and collects all errors. It is the default policy.
+	CollectAll ErrorPolicy = 0
+	// FailFast gives up on the first failure
+	FailFast ErrorPolicy = 1
+)
+
+// MaxFailures gives up once k tasks/actions are failed
+func MaxFailures(k int) ErrorPolicy {
+	if k < 0 {
+		return CollectAll
+	}
+	return ErrorPolicy(k)
+}
+
+// WithErrorPolicy set the ErrorPolicy of Wait/WaitAny/WaitN
+func WithErrorPolicy(p ErrorPolicy) Option {
+	return func(o *options) {
+		o.errorPolicy = p
+	}
+}
@@ -0,0 +1,114 @@
+package async
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorPolicy(t *testing.T) {
+
+	wantedErr := errors.New("wanted")
+
+	failed := func(ctx context.Context) (int, error) {
+		return 0, wantedErr
+	}
+
+	tests := []struct {
+		name        string
+		policy      ErrorPolicy
+		n           int
+		tasks       int
+		failed      int
+		wantedErr   error
+		wantedErrs  int
+		wantedCause error
+	}{
+		{
+			name:       "collect_all_should_work",
+			policy:     CollectAll,
+			tasks:      3,
+			failed:     3,
+			wantedErr:  ErrTooLessDone,
+			wantedErrs: 3,
+		},
+		{
+			name:        "fail_fast_should_work",
+			policy:      FailFast,
+			tasks:       2,
+			failed:      1,
+			wantedErr:   ErrTooManyFailures,
+			wantedErrs:  1,
+			wantedCause: ErrSiblingFailed,
+		},
+		{
+			name:        "max_failures_should_work",
+			policy:      MaxFailures(2),
+			tasks:       3,
+			failed:      2,
+			wantedErr:   ErrTooManyFailures,
+			wantedErrs:  2,
+			wantedCause: ErrSiblingFailed,
+		},
+		{
+			name:        "max_failures_wait_n_should_work",
+			policy:      MaxFailures(2),
+			n:           1,
+			tasks:       4,
+			failed:      2,
+			wantedErr:   ErrTooManyFailures,
+			wantedErrs:  2,
+			wantedCause: ErrSiblingFailed,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			causes := make(chan error, test.tasks)
+
+			a := New[int]().WithOptions(WithErrorPolicy(test.policy))
+			for i := 0; i < test.failed; i++ {
+				a.Add(failed)
+			}
+			for i := test.failed; i < test.tasks; i++ {
+				a.Add(func(ctx context.Context) (int, error) {
+					<-ctx.Done()
+					causes <- context.Cause(ctx)
+					return 0, ctx.Err()
+				})
+			}
+
+			var taskErrs []error
+			var err error
+			if test.n > 0 {
+				_, taskErrs, err = a.WaitN(context.Background(), test.n)
+			} else {
+				_, taskErrs, err = a.Wait(context.Background())
+			}
+
+			require.Equal(t, test.wantedErr, err)
+			require.Len(t, taskErrs, test.wantedErrs)
+			for _, taskErr := range taskErrs {
+				require.Equal(t, wantedErr, taskErr)
+			}
+			if test.wantedCause != nil {
+				require.Equal(t, test.wantedCause, <-causes)
+			}
+		})
+	}
+
+	t.Run("action_should_work", func(t *testing.T) {
+		a := NewA(func(ctx context.Context) error {
+			return wantedErr
+		}, func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}).WithOptions(WithErrorPolicy(FailFast))
+
+		taskErrs, err := a.Wait(context.Background())
+		require.Equal(t, ErrTooManyFailures, err)
+		require.Equal(t, []error{wantedErr}, taskErrs)
+	})
+}
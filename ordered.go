@@ -0,0 +1,22 @@
+package async
+
+import (
+	"context"
+	"sync"
+)
+
+// OrderedMap transforms items from in by fn with concurrency goroutines, and sends results in the order of input. The
+// Index of Result is the position of its item in input. The channel is closed once in is closed and all items are
+// transformed, or ctx is canceled.
+//
+// The reorder window is concurrency items: once the oldest item is still running and the window is full, no more items
+// are read from in. So a single slow item doesn't cause unbounded buffering.
+//
+// The panics of fn are recovered and returned as the errors of their items, which wrap ErrPanic.
+func OrderedMap[In, Out any](ctx context.Context, in <-chan In, fn func(context.Context, In) (Out, error), concurrency int) <-chan Result[Out] {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	return mapOrdered(ctx, &sync.WaitGroup{}, in, fn, concurrency, concurrency)
+}
@@ -0,0 +1,83 @@
+package async
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrderedMap(t *testing.T) {
+
+	wantedErr := errors.New("wanted")
+
+	t.Run("order_should_work", func(t *testing.T) {
+		out := OrderedMap(context.Background(), source(100), func(ctx context.Context, i int) (int, error) {
+			if i%7 == 0 {
+				time.Sleep(time.Millisecond)
+			}
+			if i == 42 {
+				return 0, wantedErr
+			}
+			return i * 2, nil
+		}, 4)
+
+		var i int
+		for r := range out {
+			require.Equal(t, i, r.Index)
+			if i == 42 {
+				require.Equal(t, wantedErr, r.Error)
+			} else {
+				require.NoError(t, r.Error)
+				require.Equal(t, i*2, r.Data)
+			}
+			i++
+		}
+		require.Equal(t, 100, i)
+	})
+
+	t.Run("window_should_work", func(t *testing.T) {
+		var read atomic.Int32
+		in := make(chan int)
+		go func() {
+			defer close(in)
+			for i := 0; i < 100; i++ {
+				in <- i
+				read.Add(1)
+			}
+		}()
+
+		slow := make(chan struct{})
+		out := OrderedMap(context.Background(), in, func(ctx context.Context, i int) (int, error) {
+			if i == 0 {
+				<-slow
+			}
+			return i, nil
+		}, 3)
+
+		time.Sleep(50 * time.Millisecond)
+		// 3 items in window, 1 item in dispatcher, and 1 item being sent
+		require.LessOrEqual(t, read.Load(), int32(5))
+
+		close(slow)
+		var n int
+		for range out {
+			n++
+		}
+		require.Equal(t, 100, n)
+	})
+
+	t.Run("cancel_should_work", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		out := OrderedMap(ctx, make(chan int), func(ctx context.Context, i int) (int, error) {
+			return i, nil
+		}, 2)
+
+		cancel()
+		for range out {
+		}
+	})
+}
@@ -0,0 +1,238 @@
+package async
+
+import (
+	"context"
+	"sync"
+)
+
+// Pipeline runs stages that are wired by channels, see Pipe. The first error of any stage cancels the whole pipeline.
+// The panics of stages are recovered as errors, which wrap ErrPanic.
+type Pipeline struct {
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+	wg     sync.WaitGroup
+}
+
+// NewPipeline create a Pipeline, which is canceled with ctx
+func NewPipeline(ctx context.Context) *Pipeline {
+	p := &Pipeline{}
+	p.ctx, p.cancel = context.WithCancelCause(ctx)
+	return p
+}
+
+// Context get the context of p, which is canceled once any stage is failed
+func (p *Pipeline) Context() context.Context {
+	return p.ctx
+}
+
+// Wait wait for all stages to exit, and return the first error of stages or the cause of ctx. The output of the last
+// stage should be drained before Wait, or it blocks until the pipeline is canceled.
+func (p *Pipeline) Wait() error {
+	p.wg.Wait()
+	err := context.Cause(p.ctx)
+	p.cancel(nil)
+	return err
+}
+
+func (p *Pipeline) fail(err error) {
+	p.cancel(err)
+}
+
+// Stage a step of Pipeline that transforms items of In to Out by Fn
+type Stage[In, Out any] struct {
+	Fn func(ctx context.Context, in In) (Out, error)
+	// Concurrency is the number of items that are transformed concurrently, it is 1 if it is less than 1
+	Concurrency int
+	// Buffer is the buffer size of the output channel
+	Buffer int
+	// Ordered keeps the output in the same order as input. Items that are completed out of order are buffered, until
+	// Concurrency+Buffer items are pending; then the stage stops reading input until the oldest one is completed.
+	Ordered bool
+	// RateLimit delays each item until it allows, if it is not nil
+	RateLimit *RateLimiter
+}
+
+// Pipe start s in p, which reads items from in and writes results to the returned channel. The channel is closed once
+// in is closed and all items are transformed, or p is canceled.
+func Pipe[In, Out any](p *Pipeline, in <-chan In, s Stage[In, Out]) <-chan Out {
+	concurrency := s.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	fn := s.Fn
+	if s.RateLimit != nil {
+		fn = func(ctx context.Context, in In) (Out, error) {
+			if err := s.RateLimit.Wait(ctx); err != nil {
+				var o Out
+				return o, err
+			}
+			return s.Fn(ctx, in)
+		}
+	}
+
+	var results <-chan Result[Out]
+	if s.Ordered {
+		results = mapOrdered(p.ctx, &p.wg, in, fn, concurrency, concurrency+s.Buffer)
+	} else {
+		results = mapUnordered(p.ctx, &p.wg, in, fn, concurrency)
+	}
+
+	out := make(chan Out, s.Buffer)
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		defer close(out)
+
+		for r := range results {
+			if r.Error != nil {
+				p.fail(r.Error)
+				return
+			}
+
+			select {
+			case out <- r.Data:
+			case <-p.ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
+// mapUnordered transforms items from in by fn with concurrency goroutines, and sends results as soon as they are completed
+func mapUnordered[In, Out any](ctx context.Context, wg *sync.WaitGroup, in <-chan In, fn func(context.Context, In) (Out, error), concurrency int) <-chan Result[Out] {
+	results := make(chan Result[Out])
+
+	var workers sync.WaitGroup
+	workers.Add(concurrency)
+	wg.Add(concurrency + 1)
+	for i := 0; i < concurrency; i++ {
+		go func() {
+			defer wg.Done()
+			defer workers.Done()
+
+			for {
+				var v In
+				var ok bool
+				select {
+				case v, ok = <-in:
+					if !ok {
+						return
+					}
+				case <-ctx.Done():
+					return
+				}
+
+				o, err := tryMap(ctx, fn, v)
+				select {
+				case results <- Result[Out]{Data: o, Error: err}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	go func() {
+		defer wg.Done()
+		workers.Wait()
+		close(results)
+	}()
+
+	return results
+}
+
+type pending[In, Out any] struct {
+	index  int
+	item   In
+	result chan Result[Out]
+}
+
+// mapOrdered transforms items from in by fn with concurrency goroutines, and sends results in the order of input. At
+// most window items are pending in the reorder window, so a slow item doesn't cause unbounded buffering.
+func mapOrdered[In, Out any](ctx context.Context, wg *sync.WaitGroup, in <-chan In, fn func(context.Context, In) (Out, error), concurrency, window int) <-chan Result[Out] {
+	if window < concurrency {
+		window = concurrency
+	}
+
+	jobs := make(chan pending[In, Out])
+	// queue holds the items in the window by input order, sending to it blocks once the window is full
+	queue := make(chan chan Result[Out], window)
+	results := make(chan Result[Out])
+
+	wg.Add(concurrency + 2)
+
+	go func() {
+		defer wg.Done()
+		defer close(jobs)
+		defer close(queue)
+
+		for i := 0; ; i++ {
+			var v In
+			var ok bool
+			select {
+			case v, ok = <-in:
+				if !ok {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+
+			j := pending[In, Out]{index: i, item: v, result: make(chan Result[Out], 1)}
+			select {
+			case queue <- j.result:
+			case <-ctx.Done():
+				return
+			}
+
+			select {
+			case jobs <- j:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	for i := 0; i < concurrency; i++ {
+		go func() {
+			defer wg.Done()
+
+			for j := range jobs {
+				o, err := tryMap(ctx, fn, j.item)
+				j.result <- Result[Out]{Index: j.index, Data: o, Error: err}
+			}
+		}()
+	}
+
+	go func() {
+		defer wg.Done()
+		defer close(results)
+
+		for c := range queue {
+			var r Result[Out]
+			select {
+			case r = <-c:
+			case <-ctx.Done():
+				return
+			}
+
+			select {
+			case results <- r:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return results
+}
+
+// tryMap transform v by fn, and return its panic as ErrPanic
+func tryMap[In, Out any](ctx context.Context, fn func(context.Context, In) (Out, error), v In) (Out, error) {
+	return try(ctx, func(ctx context.Context) (Out, error) {
+		return fn(ctx, v)
+	})
+}
@@ -0,0 +1,146 @@
+package async
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func source(n int) <-chan int {
+	c := make(chan int)
+	go func() {
+		defer close(c)
+		for i := 0; i < n; i++ {
+			c <- i
+		}
+	}()
+	return c
+}
+
+func TestPipeline(t *testing.T) {
+
+	wantedErr := errors.New("wanted")
+
+	t.Run("ordered_should_work", func(t *testing.T) {
+		p := NewPipeline(context.Background())
+
+		squares := Pipe(p, source(50), Stage[int, int]{
+			Fn: func(ctx context.Context, i int) (int, error) {
+				time.Sleep(time.Duration(50-i) * 100 * time.Microsecond)
+				return i * i, nil
+			},
+			Concurrency: 8,
+			Buffer:      4,
+			Ordered:     true,
+		})
+
+		texts := Pipe(p, squares, Stage[int, string]{
+			Fn: func(ctx context.Context, i int) (string, error) {
+				return strconv.Itoa(i), nil
+			},
+			Concurrency: 3,
+			Ordered:     true,
+		})
+
+		var result []string
+		for s := range texts {
+			result = append(result, s)
+		}
+
+		require.NoError(t, p.Wait())
+		require.Len(t, result, 50)
+		for i, s := range result {
+			require.Equal(t, strconv.Itoa(i*i), s)
+		}
+	})
+
+	t.Run("unordered_should_work", func(t *testing.T) {
+		p := NewPipeline(context.Background())
+
+		var running, peak atomic.Int32
+		out := Pipe(p, source(20), Stage[int, int]{
+			Fn: func(ctx context.Context, i int) (int, error) {
+				if r := running.Add(1); r > peak.Load() {
+					peak.Store(r)
+				}
+				time.Sleep(time.Millisecond)
+				running.Add(-1)
+				return i, nil
+			},
+			Concurrency: 4,
+		})
+
+		var result []int
+		for i := range out {
+			result = append(result, i)
+		}
+
+		require.NoError(t, p.Wait())
+		slices.Sort(result)
+		require.Len(t, result, 20)
+		require.Equal(t, 19, result[19])
+		require.LessOrEqual(t, peak.Load(), int32(4))
+	})
+
+	t.Run("error_should_cancel_pipeline", func(t *testing.T) {
+		p := NewPipeline(context.Background())
+
+		in := make(chan int)
+		go func() {
+			defer close(in)
+			for i := 0; ; i++ {
+				select {
+				case in <- i:
+				case <-p.Context().Done():
+					return
+				}
+			}
+		}()
+
+		out := Pipe(p, in, Stage[int, int]{
+			Fn: func(ctx context.Context, i int) (int, error) {
+				if i == 10 {
+					return 0, wantedErr
+				}
+				return i, nil
+			},
+			Concurrency: 2,
+			Ordered:     true,
+		})
+
+		out = Pipe(p, out, Stage[int, int]{
+			Fn: func(ctx context.Context, i int) (int, error) {
+				return i, nil
+			},
+		})
+
+		for range out {
+		}
+
+		require.Equal(t, wantedErr, p.Wait())
+		require.Equal(t, wantedErr, context.Cause(p.Context()))
+	})
+
+	t.Run("cancel_should_work", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		p := NewPipeline(ctx)
+
+		out := Pipe(p, make(chan int), Stage[int, int]{
+			Fn: func(ctx context.Context, i int) (int, error) {
+				return i, nil
+			},
+		})
+
+		cancel()
+		for range out {
+		}
+
+		require.Equal(t, context.Canceled, p.Wait())
+	})
+}
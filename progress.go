@@ -0,0 +1,130 @@
+package async
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Progress the aggregate progress of tasks/actions in a Wait/WaitAny/WaitN
+type Progress struct {
+	// Completed is the number of tasks/actions that are completed without error
+	Completed int
+	// Failed is the number of tasks/actions that are completed with error
+	Failed int
+	// Total is the number of tasks/actions that are started
+	Total int
+	// Elapsed is the time since the Wait/WaitAny/WaitN is started
+	Elapsed time.Duration
+	// Fraction is the overall progress in [0, 1], includes the progress reported by running tasks/actions
+	Fraction float64
+}
+
+// tracker tracks the progress of tasks/actions in a Wait/WaitAny/WaitN
+type tracker struct {
+	mu      sync.Mutex
+	fn      func(Progress)
+	clock   Clock
+	started time.Time
+	p       Progress
+	partial map[int]float64
+	done    map[int]bool
+}
+
+type trackerKey struct{}
+
+func newTracker(fn func(Progress), clock Clock) *tracker {
+	if fn == nil {
+		return nil
+	}
+
+	return &tracker{
+		fn:      fn,
+		clock:   clock,
+		started: clock.Now(),
+		partial: make(map[int]float64),
+		done:    make(map[int]bool),
+	}
+}
+
+// add adds a task/action that is started
+func (t *tracker) add() {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.p.Total++
+}
+
+// complete marks the task/action at index i as completed
+func (t *tracker) complete(i int, err error) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.partial, i)
+	t.done[i] = true
+	if err != nil {
+		t.p.Failed++
+	} else {
+		t.p.Completed++
+	}
+
+	t.report()
+}
+
+func (t *tracker) update(i int, fraction float64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.done[i] {
+		return
+	}
+
+	t.partial[i] = fraction
+	t.report()
+}
+
+// report calls fn with current progress, it must be called with mu held
+func (t *tracker) report() {
+	p := t.p
+	p.Elapsed = t.clock.Now().Sub(t.started)
+
+	if p.Total > 0 {
+		sum := float64(p.Completed + p.Failed)
+		for _, f := range t.partial {
+			sum += f
+		}
+		p.Fraction = sum / float64(p.Total)
+	}
+
+	t.fn(p)
+}
+
+// ReportProgress report the progress in [0, 1] of current task/action, it is rolled up into the aggregate Progress.
+// It is ignored if the Waiter/Awaiter has no WithProgress option.
+func ReportProgress(ctx context.Context, fraction float64) {
+	t, ok := ctx.Value(trackerKey{}).(*tracker)
+	if !ok {
+		return
+	}
+
+	info, ok := InfoFrom(ctx)
+	if !ok {
+		return
+	}
+
+	if fraction < 0 {
+		fraction = 0
+	} else if fraction > 1 {
+		fraction = 1
+	}
+
+	t.update(info.Index, fraction)
+}
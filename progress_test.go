@@ -0,0 +1,63 @@
+package async
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestProgress(t *testing.T) {
+
+	wantedErr := errors.New("wanted")
+
+	t.Run("task_should_work", func(t *testing.T) {
+		var reports []Progress
+
+		a := New[int](func(ctx context.Context) (int, error) {
+			return 1, nil
+		}, func(ctx context.Context) (int, error) {
+			return 0, wantedErr
+		}).WithOptions(WithProgress(func(p Progress) {
+			reports = append(reports, p)
+		}))
+
+		_, _, err := a.Wait(context.Background())
+		require.ErrorIs(t, err, ErrTooLessDone)
+
+		require.Len(t, reports, 2)
+		last := reports[1]
+		require.Equal(t, 1, last.Completed)
+		require.Equal(t, 1, last.Failed)
+		require.Equal(t, 2, last.Total)
+		require.Equal(t, 1.0, last.Fraction)
+		require.Positive(t, last.Elapsed)
+	})
+
+	t.Run("report_progress_should_work", func(t *testing.T) {
+		var fractions []float64
+
+		a := NewA(func(ctx context.Context) error {
+			ReportProgress(ctx, 0.5)
+			ReportProgress(ctx, -1)
+			return nil
+		}).WithOptions(WithProgress(func(p Progress) {
+			fractions = append(fractions, p.Fraction)
+		}))
+
+		_, err := a.Wait(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, []float64{0.5, 0, 1}, fractions)
+	})
+
+	t.Run("without_progress_should_work", func(t *testing.T) {
+		a := NewA(func(ctx context.Context) error {
+			ReportProgress(ctx, 0.5)
+			return nil
+		})
+
+		_, err := a.Wait(context.Background())
+		require.NoError(t, err)
+	})
+}
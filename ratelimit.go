@@ -0,0 +1,82 @@
+package async
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RateLimiter a token bucket that limits how often tasks/actions are started. It is safe to be shared by multiple
+// Waiters/Awaiters/Stages to enforce a global rate, see WithRateLimit.
+type RateLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter create a RateLimiter that allows rate tokens per second on average, and at most burst tokens at once.
+// There is no limit if rate is not positive.
+func NewRateLimiter(rate float64, burst int) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &RateLimiter{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+	}
+}
+
+// Wait wait for a token. The cause of ctx is returned if it is canceled before that, and the token is given back.
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return context.Cause(ctx)
+	}
+
+	d := l.reserve(ClockFrom(ctx).Now())
+	if d <= 0 {
+		return nil
+	}
+
+	if !sleep(ctx, d) {
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return context.Cause(ctx)
+	}
+
+	return nil
+}
+
+// reserve take a token, and return how long to wait for it
+func (l *RateLimiter) reserve(now time.Time) time.Duration {
+	if l.rate <= 0 {
+		return 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// the bucket is full until the first token is taken
+	if l.last.IsZero() {
+		l.last = now
+	}
+
+	if now.After(l.last) {
+		l.tokens += now.Sub(l.last).Seconds() * l.rate
+		if l.tokens > l.burst {
+			l.tokens = l.burst
+		}
+		l.last = now
+	}
+
+	l.tokens--
+	if l.tokens >= 0 {
+		return 0
+	}
+
+	return time.Duration(-l.tokens / l.rate * float64(time.Second))
+}
@@ -0,0 +1,108 @@
+package async
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimiter(t *testing.T) {
+
+	t.Run("burst_should_work", func(t *testing.T) {
+		l := NewRateLimiter(100, 3)
+		now := time.Now()
+		l.last = now
+
+		require.Zero(t, l.reserve(now))
+		require.Zero(t, l.reserve(now))
+		require.Zero(t, l.reserve(now))
+		require.Equal(t, 10*time.Millisecond, l.reserve(now))
+		require.Equal(t, 20*time.Millisecond, l.reserve(now))
+
+		// tokens are refilled, but never more than burst
+		require.Equal(t, 10*time.Millisecond, l.reserve(now.Add(20*time.Millisecond)))
+		require.Zero(t, l.reserve(now.Add(time.Second)))
+		require.InDelta(t, 2, l.tokens, 0.001)
+	})
+
+	t.Run("unlimited_should_work", func(t *testing.T) {
+		l := NewRateLimiter(0, 1)
+		for i := 0; i < 10; i++ {
+			require.NoError(t, l.Wait(context.Background()))
+		}
+	})
+
+	t.Run("cancel_should_give_token_back", func(t *testing.T) {
+		l := NewRateLimiter(1, 1)
+		require.NoError(t, l.Wait(context.Background()))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		require.Equal(t, context.DeadlineExceeded, l.Wait(ctx))
+		require.InDelta(t, 0, l.tokens, 0.1)
+
+		require.Equal(t, context.DeadlineExceeded, l.Wait(ctx))
+	})
+
+	t.Run("waiters_should_share_limit", func(t *testing.T) {
+		l := NewRateLimiter(200, 1)
+
+		task := func(ctx context.Context) (int, error) {
+			return 1, nil
+		}
+		a := New[int](task, task, task).WithOptions(WithRateLimit(l))
+		b := NewA(func(ctx context.Context) error {
+			return nil
+		}, func(ctx context.Context) error {
+			return nil
+		}).WithOptions(WithRateLimit(l))
+
+		started := time.Now()
+		done := make(chan struct{})
+		go func() {
+			_, _ = b.Wait(context.Background())
+			close(done)
+		}()
+		_, _, err := a.Wait(context.Background())
+		require.NoError(t, err)
+		<-done
+
+		// 1 token at once, then 4 tokens at 5ms each
+		require.GreaterOrEqual(t, time.Since(started), 20*time.Millisecond)
+	})
+
+	t.Run("canceled_task_should_not_start", func(t *testing.T) {
+		l := NewRateLimiter(1, 1)
+		var started int
+		a := New[int](func(ctx context.Context) (int, error) {
+			started++
+			return 1, nil
+		}, func(ctx context.Context) (int, error) {
+			started++
+			return 1, nil
+		}).WithOptions(WithRateLimit(l))
+
+		_, _, err := a.WaitAny(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, 1, started)
+	})
+
+	t.Run("stage_should_work", func(t *testing.T) {
+		p := NewPipeline(context.Background())
+		out := Pipe(p, source(5), Stage[int, int]{
+			Fn: func(ctx context.Context, i int) (int, error) {
+				return i, nil
+			},
+			Concurrency: 5,
+			RateLimit:   NewRateLimiter(200, 1),
+		})
+
+		started := time.Now()
+		for range out {
+		}
+		require.NoError(t, p.Wait())
+		require.GreaterOrEqual(t, time.Since(started), 20*time.Millisecond)
+	})
+}
@@ -0,0 +1,17 @@
+package async
+
+import (
+	"context"
+)
+
+// Reduce wait for all tasks of w to completed, and fold their results into init by fn as soon as they are completed.
+// Results are not collected, so memory stays constant no matter how many tasks there are.
+func Reduce[T, Acc any](ctx context.Context, w Waiter[T], init Acc, fn func(Acc, T) Acc) (Acc, []error, error) {
+	acc := init
+
+	taskErrs, err := w.Each(ctx, func(item T) {
+		acc = fn(acc, item)
+	})
+
+	return acc, taskErrs, err
+}
@@ -0,0 +1,53 @@
+package async
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReduce(t *testing.T) {
+
+	wantedErr := errors.New("wanted")
+
+	t.Run("sum_should_work", func(t *testing.T) {
+		a := New[int]()
+		for i := 1; i <= 100; i++ {
+			i := i
+			a.Add(func(ctx context.Context) (int, error) {
+				return i, nil
+			})
+		}
+
+		sum, taskErrs, err := Reduce(context.Background(), a, 0, func(acc int, i int) int {
+			return acc + i
+		})
+
+		require.NoError(t, err)
+		require.Nil(t, taskErrs)
+		require.Equal(t, 5050, sum)
+	})
+
+	t.Run("merge_should_work", func(t *testing.T) {
+		a := New[map[string]int](func(ctx context.Context) (map[string]int, error) {
+			return map[string]int{"a": 1}, nil
+		}, func(ctx context.Context) (map[string]int, error) {
+			return map[string]int{"b": 2}, nil
+		}, func(ctx context.Context) (map[string]int, error) {
+			return nil, wantedErr
+		})
+
+		merged, taskErrs, err := Reduce(context.Background(), a, map[string]int{}, func(acc map[string]int, m map[string]int) map[string]int {
+			for k, v := range m {
+				acc[k] = v
+			}
+			return acc
+		})
+
+		require.ErrorIs(t, err, ErrTooLessDone)
+		require.Equal(t, []error{wantedErr}, taskErrs)
+		require.Equal(t, map[string]int{"a": 1, "b": 2}, merged)
+	})
+}
@@ -1,6 +1,8 @@
 package async
 
 type Result[T any] struct {
+	// Index is the position of the task in the Waiter
+	Index int
 	Data  T
 	Error error
 }
@@ -0,0 +1,255 @@
+package async
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// OverlapPolicy what a periodic schedule does when it is due while its previous run is still running
+type OverlapPolicy int
+
+const (
+	// OverlapSkip skips the run. It is the default policy.
+	OverlapSkip OverlapPolicy = iota
+	// OverlapQueue starts the run once the previous one is completed. At most one run is queued.
+	OverlapQueue
+	// OverlapAllow starts the run concurrently
+	OverlapAllow
+)
+
+// ScheduleOption configures a schedule of Scheduler
+type ScheduleOption func(o *scheduleOptions)
+
+type scheduleOptions struct {
+	name    string
+	jitter  time.Duration
+	overlap OverlapPolicy
+}
+
+// WithName set the name of the scheduled action in TaskInfo
+func WithName(name string) ScheduleOption {
+	return func(o *scheduleOptions) {
+		o.name = name
+	}
+}
+
+// WithJitter delay each run by a random duration in [0, d), so the runs of many instances are spread out
+func WithJitter(d time.Duration) ScheduleOption {
+	return func(o *scheduleOptions) {
+		o.jitter = d
+	}
+}
+
+// WithOverlap set the OverlapPolicy of a periodic schedule
+func WithOverlap(p OverlapPolicy) ScheduleOption {
+	return func(o *scheduleOptions) {
+		o.overlap = p
+	}
+}
+
+// Scheduler runs actions after a delay, on an interval or by cron expressions. Each schedule is stopped once its
+// context is canceled. The runs are reported to the Observer of WithObserver with TaskInfo, whose Index is the position
+// of the schedule in Scheduler and Attempt is the 1-based number of the run. The panics of actions are recovered and
+// reported as their errors, which wrap ErrPanic.
+type Scheduler struct {
+	id uint64
+	wg sync.WaitGroup
+
+	mu        sync.Mutex
+	observer  *Observer
+	schedules int
+}
+
+// NewScheduler create an empty Scheduler
+func NewScheduler() *Scheduler {
+	return &Scheduler{
+		id: lastWaiterID.Add(1),
+	}
+}
+
+// WithOptions apply options to the schedules that are added later. Only WithObserver is supported, the others are ignored.
+func (s *Scheduler) WithOptions(opts ...Option) *Scheduler {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	o := options{observer: s.observer}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	s.observer = o.observer
+	return s
+}
+
+// After run action once after d, unless ctx is canceled before that
+func (s *Scheduler) After(ctx context.Context, d time.Duration, action Action, opts ...ScheduleOption) {
+	sc := s.schedule(ctx, action, opts)
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		if sc.sleep(d) {
+			sc.fire()
+		}
+	}()
+}
+
+// Every run action on interval until ctx is canceled, the first run is started after interval. The runs are started
+// at a fixed rate regardless of how long they take, see OverlapPolicy.
+func (s *Scheduler) Every(ctx context.Context, interval time.Duration, action Action, opts ...ScheduleOption) {
+	sc := s.schedule(ctx, action, opts)
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		clock := ClockFrom(ctx)
+		next := clock.Now()
+		for {
+			next = next.Add(interval)
+			// skip the runs that are missed, e.g. the process is suspended
+			now := clock.Now()
+			if next.Before(now) {
+				next = now
+			}
+
+			if !sc.sleep(next.Sub(now)) {
+				return
+			}
+			sc.fire()
+		}
+	}()
+}
+
+// Cron run action at the times that match the cron expression in local time until ctx is canceled, see ParseCron.
+// ErrInvalidCron is returned if expr can't be parsed.
+func (s *Scheduler) Cron(ctx context.Context, expr string, action Action, opts ...ScheduleOption) error {
+	c, err := ParseCron(expr)
+	if err != nil {
+		return err
+	}
+
+	sc := s.schedule(ctx, action, opts)
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		clock := ClockFrom(ctx)
+		for {
+			now := clock.Now()
+			next := c.Next(now)
+			if next.IsZero() || !sc.sleep(next.Sub(now)) {
+				return
+			}
+			sc.fire()
+		}
+	}()
+
+	return nil
+}
+
+// Wait wait for all schedules to be stopped and their runs to be completed
+func (s *Scheduler) Wait() {
+	s.wg.Wait()
+}
+
+func (s *Scheduler) schedule(ctx context.Context, action Action, opts []ScheduleOption) *schedule {
+	var o scheduleOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sc := &schedule{
+		s:        s,
+		ctx:      ctx,
+		action:   action,
+		opts:     o,
+		index:    s.schedules,
+		observer: s.observer,
+	}
+	s.schedules++
+	return sc
+}
+
+// schedule an action that is scheduled in Scheduler
+type schedule struct {
+	s        *Scheduler
+	ctx      context.Context
+	action   Action
+	opts     scheduleOptions
+	index    int
+	observer *Observer
+
+	mu      sync.Mutex
+	runs    int
+	running int
+	queued  bool
+}
+
+// sleep wait for d and the jitter, it returns false if ctx is canceled before that
+func (sc *schedule) sleep(d time.Duration) bool {
+	if sc.opts.jitter > 0 {
+		d += time.Duration(rand.Int63n(int64(sc.opts.jitter)))
+	}
+
+	return sleep(sc.ctx, d)
+}
+
+// fire start a run by OverlapPolicy
+func (sc *schedule) fire() {
+	sc.mu.Lock()
+	if sc.running > 0 && sc.opts.overlap != OverlapAllow {
+		if sc.opts.overlap == OverlapQueue {
+			sc.queued = true
+		}
+		sc.mu.Unlock()
+		return
+	}
+	sc.running++
+	sc.mu.Unlock()
+
+	sc.s.wg.Add(1)
+	go func() {
+		defer sc.s.wg.Done()
+
+		for sc.run() {
+		}
+	}()
+}
+
+// run run action once, it returns true if another run is queued
+func (sc *schedule) run() bool {
+	sc.mu.Lock()
+	sc.runs++
+	info := TaskInfo{
+		WaiterID: sc.s.id,
+		Index:    sc.index,
+		Name:     sc.opts.name,
+		Attempt:  sc.runs,
+	}
+	sc.mu.Unlock()
+
+	clock := ClockFrom(sc.ctx)
+	sc.observer.start(info)
+	started := clock.Now()
+	err := tryA(withInfo(sc.ctx, info), sc.action)
+	sc.observer.done(info, err, clock.Now().Sub(started))
+
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	if sc.queued && sc.ctx.Err() == nil {
+		sc.queued = false
+		return true
+	}
+
+	sc.queued = false
+	sc.running--
+	return false
+}
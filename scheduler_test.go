@@ -0,0 +1,137 @@
+package async
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestScheduler(t *testing.T) {
+
+	t.Run("after_should_run_once", func(t *testing.T) {
+		s := NewScheduler()
+
+		var runs atomic.Int32
+		s.After(context.Background(), 10*time.Millisecond, func(ctx context.Context) error {
+			runs.Add(1)
+			return nil
+		})
+		s.Wait()
+
+		require.Equal(t, int32(1), runs.Load())
+	})
+
+	t.Run("after_should_be_stopped_by_ctx", func(t *testing.T) {
+		s := NewScheduler()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		var runs atomic.Int32
+		s.After(ctx, time.Minute, func(ctx context.Context) error {
+			runs.Add(1)
+			return nil
+		})
+
+		cancel()
+		s.Wait()
+		require.Zero(t, runs.Load())
+	})
+
+	t.Run("every_should_run_until_ctx_is_canceled", func(t *testing.T) {
+		s := NewScheduler()
+
+		var mu sync.Mutex
+		var infos []TaskInfo
+		s.WithOptions(WithObserver(Observer{
+			OnDone: func(info TaskInfo, err error, elapsed time.Duration) {
+				mu.Lock()
+				defer mu.Unlock()
+				infos = append(infos, info)
+			},
+		}))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		s.Every(ctx, 5*time.Millisecond, func(ctx context.Context) error {
+			info, ok := InfoFrom(ctx)
+			require.True(t, ok)
+			require.Equal(t, "tick", info.Name)
+			if info.Attempt == 3 {
+				cancel()
+			}
+			return nil
+		}, WithName("tick"), WithJitter(time.Millisecond))
+		s.Wait()
+
+		require.Len(t, infos, 3)
+		for i, info := range infos {
+			require.Equal(t, i+1, info.Attempt)
+			require.Equal(t, "tick", info.Name)
+		}
+	})
+
+	overlap := func(p OverlapPolicy) (runs, peak int32) {
+		s := NewScheduler()
+
+		var running, max, count atomic.Int32
+		ctx, cancel := context.WithTimeout(context.Background(), 55*time.Millisecond)
+		defer cancel()
+
+		s.Every(ctx, 10*time.Millisecond, func(ctx context.Context) error {
+			if r := running.Add(1); r > max.Load() {
+				max.Store(r)
+			}
+			count.Add(1)
+			time.Sleep(25 * time.Millisecond)
+			running.Add(-1)
+			return nil
+		}, WithOverlap(p))
+		s.Wait()
+
+		return count.Load(), max.Load()
+	}
+
+	t.Run("overlap_skip_should_skip_runs", func(t *testing.T) {
+		runs, peak := overlap(OverlapSkip)
+		require.Equal(t, int32(1), peak)
+		// started at 10ms and 40ms
+		require.LessOrEqual(t, runs, int32(2))
+	})
+
+	t.Run("overlap_queue_should_run_one_after_another", func(t *testing.T) {
+		runs, peak := overlap(OverlapQueue)
+		require.Equal(t, int32(1), peak)
+		// started at 10ms, 35ms and 60ms, the queued runs are not started once ctx is canceled
+		require.GreaterOrEqual(t, runs, int32(2))
+	})
+
+	t.Run("overlap_allow_should_run_concurrently", func(t *testing.T) {
+		runs, peak := overlap(OverlapAllow)
+		require.Greater(t, peak, int32(1))
+		require.GreaterOrEqual(t, runs, int32(4))
+	})
+
+	t.Run("invalid_cron_should_fail", func(t *testing.T) {
+		s := NewScheduler()
+		err := s.Cron(context.Background(), "* * *", func(ctx context.Context) error {
+			return nil
+		})
+		require.ErrorIs(t, err, ErrInvalidCron)
+		s.Wait()
+	})
+
+	t.Run("cron_should_be_stopped_by_ctx", func(t *testing.T) {
+		s := NewScheduler()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		err := s.Cron(ctx, "@hourly", func(ctx context.Context) error {
+			return nil
+		})
+		require.NoError(t, err)
+
+		cancel()
+		s.Wait()
+	})
+}
@@ -2,105 +2,497 @@ package async
 
 import (
 	"context"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
 )
 
+// Waiter waits for tasks. It is safe to add tasks concurrently, even from the tasks that are running. By default
+// tasks that are added during a Wait/WaitAny/WaitN are started in the next one, see WithDynamic.
+//
+// A Waiter can be reused: all tasks are started again in each Wait/WaitAny/WaitN, unless WithMemoize is used.
+//
+// The panics of tasks are recovered and returned as their errors, which wrap ErrPanic.
 type Waiter[T any] interface {
 	// Add add a task
 	Add(task Task[T])
+	// AddNamed add a task with name, see TaskInfo
+	AddNamed(name string, task Task[T])
+	// WithOptions apply options to the waiter
+	WithOptions(opts ...Option) Waiter[T]
+	// Len get the number of tasks
+	Len() int
+	// Remove remove the task at index i, return false if it doesn't exist
+	Remove(i int) bool
+	// Reset forget the cached results, so all tasks are started again in next wait, see WithMemoize
+	Reset()
 	// Wait wail for all tasks to completed
 	Wait(context.Context) ([]T, []error, error)
+	// Each wait for all tasks to completed, and call fn with the result of each task as soon as it is completed.
+	// fn is called on the waiting goroutine one by one, so it is safe to update state without lock in it.
+	Each(ctx context.Context, fn func(T)) ([]error, error)
 	// WaitAny wait for any task to completed without error, can cancel other tasks
 	WaitAny(context.Context) (T, []error, error)
-	// WaitN wait for N tasks to completed without error
+	// WaitN wait for N tasks to completed without error. The tasks that are still running are canceled with
+	// ErrQuorumReached once N tasks are completed, or with ErrSiblingFailed once N tasks can't be completed any more.
+	// The cause of ctx is returned if it is canceled before that, see context.Cause.
 	WaitN(context.Context, int) ([]T, []error, error)
 }
 
+type job[T any] struct {
+	name     string
+	task     Task[T]
+	attempts int
+	// result is the cached result of task, see WithMemoize
+	result *Result[T]
+}
+
+// outcome the result of a job in a run
+type outcome[T any] struct {
+	job *job[T]
+	Result[T]
+}
+
+// run an in-progress Wait/WaitAny/WaitN
+type run[T any] struct {
+	waiter *waiter[T]
+	// opts are the options of waiter when the run is started
+	opts    options
+	ctx     context.Context
+	wait    chan outcome[T]
+	tracker *tracker
+	total   int
+	// inline are the tasks that are not started yet, and pick chooses the next one of them, see InlineExecutor
+	inline []runner
+	pick   func(n int) int
+}
+
 type waiter[T any] struct {
-	tasks []Task[T]
+	id uint64
+
+	mu   sync.Mutex
+	jobs []*job[T]
+	opts options
+	runs map[*run[T]]struct{}
+}
+
+func newWaiter[T any](tasks ...Task[T]) *waiter[T] {
+	a := &waiter[T]{
+		id:   lastWaiterID.Add(1),
+		runs: make(map[*run[T]]struct{}),
+	}
+
+	for _, task := range tasks {
+		a.Add(task)
+	}
+
+	return a
+}
+
+func (a *waiter[T]) WithOptions(opts ...Option) Waiter[T] {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for _, opt := range opts {
+		opt(&a.opts)
+	}
+	return a
+}
+
+func (a *waiter[T]) Len() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return len(a.jobs)
+}
+
+func (a *waiter[T]) Remove(i int) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if i < 0 || i >= len(a.jobs) {
+		return false
+	}
+
+	a.jobs = append(a.jobs[:i], a.jobs[i+1:]...)
+	return true
+}
+
+func (a *waiter[T]) Reset() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for _, j := range a.jobs {
+		j.result = nil
+	}
 }
 
 func (a *waiter[T]) Add(task Task[T]) {
-	a.tasks = append(a.tasks, task)
+	a.AddNamed("", task)
 }
 
-func (a *waiter[T]) Wait(ctx context.Context) ([]T, []error, error) {
-	wait := make(chan Result[T])
-
-	for _, task := range a.tasks {
-		go func(task func(context.Context) (T, error)) {
-			r, err := task(ctx)
-			wait <- Result[T]{
-				Data:  r,
-				Error: err,
+func (a *waiter[T]) AddNamed(name string, task Task[T]) {
+	a.mu.Lock()
+
+	j := &job[T]{name: name, task: task}
+	a.jobs = append(a.jobs, j)
+
+	var tasks []runner
+	for r := range a.runs {
+		if t := a.launch(r, len(a.jobs)-1, j); t != nil {
+			tasks = append(tasks, t)
+		}
+	}
+	executor := a.opts.executor
+	a.mu.Unlock()
+
+	dispatch(executor, tasks)
+}
+
+// start run all tasks in a new run with current options
+func (a *waiter[T]) start(ctx context.Context) (*run[T], options) {
+	a.mu.Lock()
+
+	r := &run[T]{
+		waiter:  a,
+		opts:    a.opts,
+		wait:    make(chan outcome[T], len(a.jobs)),
+		tracker: newTracker(a.opts.progress, ClockFrom(ctx)),
+	}
+
+	if in, ok := a.opts.executor.(inliner); ok {
+		r.pick = in.picker()
+	}
+
+	if r.tracker != nil {
+		ctx = context.WithValue(ctx, trackerKey{}, r.tracker)
+	}
+	r.ctx = ctx
+
+	var tasks []runner
+	if r.pick == nil {
+		tasks = make([]runner, 0, len(a.jobs))
+	}
+	for i, j := range a.jobs {
+		if j.result != nil {
+			r.total++
+			r.tracker.add()
+			res := *j.result
+			res.Index = i
+			r.wait <- outcome[T]{job: j, Result: res}
+			continue
+		}
+
+		if a.opts.journal != nil && j.name != "" {
+			var t T
+			// the tasks whose records can't be decoded are started again
+			if ok, err := a.opts.journal.Get(j.name, &t); ok && err == nil {
+				r.total++
+				r.tracker.add()
+				r.wait <- outcome[T]{job: j, Result: Result[T]{Index: i, Data: t}}
+				continue
 			}
-		}(task)
+		}
+
+		if t := a.launch(r, i, j); t != nil {
+			tasks = append(tasks, t)
+		}
 	}
 
-	var r Result[T]
-	var taskErrs []error
-	var items []T
+	if a.opts.dynamic {
+		a.runs[r] = struct{}{}
+	}
+	a.mu.Unlock()
 
-	tt := len(a.tasks)
-	for i := 0; i < tt; i++ {
-		select {
-		case r = <-wait:
-			if r.Error != nil {
-				taskErrs = append(taskErrs, r.Error)
+	dispatch(r.opts.executor, tasks)
+
+	return r, r.opts
+}
+
+// stop stops scheduling tasks that are added later in r
+func (a *waiter[T]) stop(r *run[T]) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	delete(a.runs, r)
+}
+
+// execute run task after it is allowed by the limiters in opts
+func (a *waiter[T]) execute(ctx context.Context, task Task[T], opts *options) (T, error) {
+	var t T
+	if opts.rateLimit != nil {
+		if err := opts.rateLimit.Wait(ctx); err != nil {
+			return t, err
+		}
+	}
+
+	if opts.concurrency != nil {
+		release, err := opts.concurrency.Acquire(ctx)
+		if err != nil {
+			return t, err
+		}
+
+		t, err = try(ctx, task)
+		release(err)
+		return t, err
+	}
+
+	return try(ctx, task)
+}
+
+// try run task, and return its panic as ErrPanic
+func try[T any](ctx context.Context, task Task[T]) (t T, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if e, ok := r.(error); ok {
+				err = fmt.Errorf("%w: %w\n%s", ErrPanic, e, debug.Stack())
 			} else {
-				items = append(items, r.Data)
+				err = fmt.Errorf("%w: %v\n%s", ErrPanic, r, debug.Stack())
 			}
-		case <-ctx.Done():
-			return items, taskErrs, ctx.Err()
 		}
+	}()
+
+	return task(ctx)
+}
+
+// tryA run action, and return its panic as ErrPanic
+func tryA(ctx context.Context, action Action) error {
+	_, err := try(ctx, toTask(action))
+	return err
+}
+
+// call execute task, and dedupe it by name in the Group of opts if it has a name
+func (a *waiter[T]) call(ctx context.Context, name string, task Task[T], opts *options) (T, error) {
+	if opts.group == nil || name == "" {
+		return a.execute(ctx, task, opts)
+	}
+
+	// duplicates share the limiters of the execution that they join
+	return Dedupe(func(ctx context.Context) (T, error) {
+		return a.execute(ctx, task, opts)
+	}, opts.group, name)(ctx)
+}
+
+// remember caches the result of a job
+func (a *waiter[T]) remember(o outcome[T]) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	o.job.result = &o.Result
+}
+
+// size get the number of tasks that are started in r
+func (a *waiter[T]) size(r *run[T]) int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return r.total
+}
+
+// launch prepare the task of j with TaskInfo in r, it must be called with mu held. The task is queued in r if it is run
+// inline, otherwise it is returned, and it must be passed to dispatch after mu is released, so an Executor that blocks
+// can't deadlock with the tasks that use the waiter.
+func (a *waiter[T]) launch(r *run[T], i int, j *job[T]) runner {
+	j.attempts++
+	r.total++
+	r.tracker.add()
+
+	l := &launched[T]{
+		infoCtx: infoCtx{
+			Context: r.ctx,
+			info: TaskInfo{
+				WaiterID: a.id,
+				Index:    i,
+				Name:     j.name,
+				Attempt:  j.attempts,
+			},
+		},
+		r: r,
+		j: j,
 	}
 
-	if len(items) == tt {
-		return items, taskErrs, nil
+	if r.pick != nil {
+		r.inline = append(r.inline, l)
+		return nil
 	}
 
-	return items, taskErrs, ErrTooLessDone
+	return l
 }
 
-func (a *waiter[T]) WaitN(ctx context.Context, n int) ([]T, []error, error) {
-	wait := make(chan Result[T])
+// launched a task that is launched in a run. It is the context of the task too, so launching a task allocates once.
+type launched[T any] struct {
+	infoCtx
+	r *run[T]
+	j *job[T]
+}
 
-	cancelCtx, cancel := context.WithCancel(ctx)
-	defer cancel()
+func (l *launched[T]) run() {
+	r, j, info := l.r, l.j, l.info
+	a, opts := r.waiter, &r.opts
+	ctx := &l.infoCtx
 
-	for _, task := range a.tasks {
-		go func(task func(context.Context) (T, error)) {
-			r, err := task(cancelCtx)
-			wait <- Result[T]{
-				Data:  r,
-				Error: err,
-			}
-		}(task)
+	// the elapsed time is measured for Observer only
+	var clock Clock
+	var started time.Time
+	if opts.observer != nil {
+		clock = ClockFrom(ctx)
+		opts.observer.start(info)
+		started = clock.Now()
 	}
 
-	var r Result[T]
-	var taskErrs []error
+	var v T
+	var err error
+	switch {
+	case opts.journal != nil && j.name == "":
+		err = ErrUnnamedTask
+	case opts.watchdog > 0:
+		watch(ctx, info, opts.watchdog, opts.observer, func(ctx context.Context) {
+			v, err = a.call(ctx, j.name, j.task, opts)
+		})
+	default:
+		v, err = a.call(ctx, j.name, j.task, opts)
+	}
+	if err == nil && opts.journal != nil {
+		if jerr := opts.journal.Put(j.name, v); jerr != nil {
+			err = fmt.Errorf("async: can't record result in journal: %w", jerr)
+		}
+	}
+	if opts.observer != nil {
+		opts.observer.done(info, err, clock.Now().Sub(started))
+	}
+
+	select {
+	case r.wait <- outcome[T]{
+		job: j,
+		Result: Result[T]{
+			Index: info.Index,
+			Data:  v,
+			Error: err,
+		},
+	}:
+	case <-r.ctx.Done():
+	}
+}
+
+// dispatch run tasks on executor, or on new goroutines if it is nil
+func dispatch(executor Executor, tasks []runner) {
+	s, _ := executor.(submitter)
+	for _, t := range tasks {
+		switch {
+		case s != nil:
+			s.submit(t)
+		case executor != nil:
+			executor.Go(t.run)
+		default:
+			go t.run()
+		}
+	}
+}
+
+// next remove the next inline task from r, it returns nil if there is none
+func (a *waiter[T]) next(r *run[T]) runner {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if len(r.inline) == 0 {
+		return nil
+	}
+
+	i := r.pick(len(r.inline))
+	t := r.inline[i]
+	r.inline = append(r.inline[:i], r.inline[i+1:]...)
+	return t
+}
+
+func (a *waiter[T]) Wait(ctx context.Context) ([]T, []error, error) {
 	var items []T
-	tt := len(a.tasks)
+
+	taskErrs, err := a.Each(ctx, func(item T) {
+		items = append(items, item)
+	})
+
+	return items, taskErrs, err
+}
+
+func (a *waiter[T]) Each(ctx context.Context, fn func(T)) ([]error, error) {
+	return a.wait(ctx, 0, true, fn)
+}
+
+func (a *waiter[T]) WaitN(ctx context.Context, n int) ([]T, []error, error) {
+	var items []T
+
+	taskErrs, err := a.wait(ctx, n, false, func(item T) {
+		items = append(items, item)
+	})
+
+	return items, taskErrs, err
+}
+
+// wait start all tasks, and call fn with the result of each task that is completed without error, until n of them
+// are completed. If all is true, it waits for all tasks to completed even if some of them are failed.
+// In any case it gives up once the failures reach the ErrorPolicy.
+func (a *waiter[T]) wait(ctx context.Context, n int, all bool, fn func(T)) ([]error, error) {
+	cancelCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	r, opts := a.start(cancelCtx)
+	defer a.stop(r)
+
+	var taskErrs []error
 	var done int
-	for i := 0; i < tt; i++ {
+	var i int
+	for ; i < a.size(r); i++ {
+		// inline tasks are started one by one on the waiting goroutine when there is no result to receive, and the rest
+		// are never started once it returns
+		if r.pick != nil && len(r.wait) == 0 {
+			if t := a.next(r); t != nil {
+				t.run()
+			}
+		}
+
 		select {
-		case r = <-wait:
-			if r.Error != nil {
-				taskErrs = append(taskErrs, r.Error)
+		case res := <-r.wait:
+			if opts.memoize {
+				a.remember(res)
+			}
+
+			r.tracker.complete(res.Index, res.Error)
+			if res.Error != nil {
+				taskErrs = append(taskErrs, res.Error)
+				if opts.errorPolicy != CollectAll && len(taskErrs) >= int(opts.errorPolicy) {
+					cancel(ErrSiblingFailed)
+					return taskErrs, ErrTooManyFailures
+				}
+
+				// more tasks might be added later in dynamic mode
+				if !all && !opts.dynamic && done+a.size(r)-i-1 < n {
+					cancel(ErrSiblingFailed)
+					return taskErrs, ErrTooLessDone
+				}
 			} else {
-				items = append(items, r.Data)
+				fn(res.Data)
 				done++
-				if done == n {
-					return items, taskErrs, nil
+				if !all && done == n {
+					cancel(ErrQuorumReached)
+					return taskErrs, nil
 				}
 			}
 		case <-ctx.Done():
-			return items, taskErrs, ctx.Err()
+			return taskErrs, context.Cause(ctx)
 		}
 
 	}
 
-	return items, taskErrs, ErrTooLessDone
+	if all {
+		n = i
+	}
+
+	if done < n {
+		return taskErrs, ErrTooLessDone
+	}
+
+	return taskErrs, nil
 }
 
 func (a *waiter[T]) WaitAny(ctx context.Context) (T, []error, error) {
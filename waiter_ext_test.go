@@ -0,0 +1,346 @@
+package async_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yaitoo/async"
+	"github.com/yaitoo/async/asynctest"
+)
+
+// detached run task without the cancellation of Wait/WaitAny/WaitN, like a slow task that ignores its context
+func detached[T any](task async.Task[T]) async.Task[T] {
+	return func(ctx context.Context) (T, error) {
+		return task(context.WithoutCancel(ctx))
+	}
+}
+
+// scripted the step of a scripted test: once fast tasks are completed, the slow tasks are completed with results, and
+// the context of Wait/WaitAny/WaitN is canceled with cause if it is not nil. cause is applied before waiting if fast
+// is 0.
+type scripted struct {
+	fast    int
+	results []int
+	cause   error
+}
+
+// run call wait with a context and a progress Option that are driven by s, then complete the rest of n slow tasks
+func (s scripted) run(slow *asynctest.Script[int], n int, wait func(ctx context.Context, progress async.Option)) {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	defer cancel(nil)
+
+	progress := async.WithProgress(func(p async.Progress) {
+		if p.Completed+p.Failed != s.fast {
+			return
+		}
+		for _, v := range s.results {
+			slow.Succeed(v)
+		}
+		if s.cause != nil {
+			cancel(s.cause)
+		}
+	})
+
+	if s.fast == 0 && s.cause != nil {
+		cancel(s.cause)
+	}
+
+	wait(ctx, progress)
+
+	for i := 0; i < n; i++ {
+		slow.Succeed(0)
+	}
+}
+
+func TestWait(t *testing.T) {
+
+	wantedErr := errors.New("wanted")
+	wantedErrs := []error{wantedErr}
+
+	fast := func(v int, err error) async.Task[int] {
+		return func(ctx context.Context) (int, error) {
+			return v, err
+		}
+	}
+
+	tests := []struct {
+		name         string
+		setup        func(slow async.Task[int]) async.Waiter[int]
+		scripted     scripted
+		wantedResult []int
+		wantedErr    error
+		wantedErrs   []error
+	}{
+		{
+			name: "wait_should_work",
+			setup: func(slow async.Task[int]) async.Waiter[int] {
+				a := async.New[int](fast(1, nil), fast(2, nil))
+				a.Add(fast(3, nil))
+				return a
+			},
+			wantedResult: []int{1, 2, 3},
+			wantedErr:    nil,
+		},
+		{
+			name: "error_should_work",
+			setup: func(slow async.Task[int]) async.Waiter[int] {
+				return async.New[int](fast(1, nil), fast(2, nil), fast(0, wantedErr))
+			},
+			wantedResult: []int{1, 2},
+			wantedErr:    async.ErrTooLessDone,
+			wantedErrs:   wantedErrs,
+		},
+		{
+			name: "errors_should_work",
+			setup: func(slow async.Task[int]) async.Waiter[int] {
+				return async.New[int](fast(0, wantedErr), fast(0, wantedErr), fast(0, wantedErr))
+			},
+			wantedErr:  async.ErrTooLessDone,
+			wantedErrs: []error{wantedErr, wantedErr, wantedErr},
+		},
+		{
+			name: "slow_should_be_waited",
+			setup: func(slow async.Task[int]) async.Waiter[int] {
+				return async.New[int](slow, slow, fast(3, nil))
+			},
+			scripted:     scripted{fast: 1, results: []int{1, 2}},
+			wantedResult: []int{1, 2, 3},
+		},
+		{
+			name: "context_should_work",
+			setup: func(slow async.Task[int]) async.Waiter[int] {
+				return async.New[int](slow, slow, fast(3, nil))
+			},
+			scripted:     scripted{fast: 1, cause: context.DeadlineExceeded},
+			wantedResult: []int{3},
+			wantedErr:    context.DeadlineExceeded,
+		},
+		{
+			name: "cancel_should_work",
+			setup: func(slow async.Task[int]) async.Waiter[int] {
+				return async.New[int](slow, slow, fast(3, nil))
+			},
+			scripted:     scripted{fast: 1, cause: context.Canceled},
+			wantedResult: []int{3},
+			wantedErr:    context.Canceled,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			slow := asynctest.NewScript[int]()
+			a := test.setup(detached(slow.Task()))
+
+			var result []int
+			var err error
+			var taskErrs []error
+
+			test.scripted.run(slow, a.Len(), func(ctx context.Context, progress async.Option) {
+				result, taskErrs, err = a.WithOptions(progress).Wait(ctx)
+			})
+
+			slices.Sort(result)
+
+			require.Equal(t, test.wantedResult, result)
+			require.Equal(t, test.wantedErr, err)
+			require.Equal(t, test.wantedErrs, taskErrs)
+		})
+	}
+}
+
+func TestWaitAny(t *testing.T) {
+
+	wantedErr := errors.New("wanted")
+
+	fast := func(v int, err error) async.Task[int] {
+		return func(ctx context.Context) (int, error) {
+			return v, err
+		}
+	}
+
+	tests := []struct {
+		name         string
+		setup        func(slow async.Task[int]) async.Waiter[int]
+		scripted     scripted
+		wantedResult int
+		wantedErr    error
+		wantedErrs   []error
+	}{
+		{
+			name: "1st_should_work",
+			setup: func(slow async.Task[int]) async.Waiter[int] {
+				a := async.New[int](slow, slow)
+				a.Add(fast(1, nil))
+				return a
+			},
+			wantedResult: 1,
+		},
+		{
+			name: "2nd_should_work",
+			setup: func(slow async.Task[int]) async.Waiter[int] {
+				return async.New[int](slow, fast(2, nil), slow)
+			},
+			wantedResult: 2,
+		},
+		{
+			name: "3rd_should_work",
+			setup: func(slow async.Task[int]) async.Waiter[int] {
+				return async.New[int](slow, slow, fast(3, nil))
+			},
+			wantedResult: 3,
+		},
+		{
+			name: "slowest_should_work",
+			setup: func(slow async.Task[int]) async.Waiter[int] {
+				return async.New[int](slow, fast(0, wantedErr), fast(0, wantedErr))
+			},
+			scripted:     scripted{fast: 2, results: []int{1}},
+			wantedResult: 1,
+			wantedErrs:   []error{wantedErr, wantedErr},
+		},
+		{
+			name: "fastest_should_work",
+			setup: func(slow async.Task[int]) async.Waiter[int] {
+				return async.New[int](fast(1, nil), slow, slow)
+			},
+			wantedResult: 1,
+		},
+		{
+			name: "errors_should_work",
+			setup: func(slow async.Task[int]) async.Waiter[int] {
+				return async.New[int](fast(0, wantedErr), fast(0, wantedErr), fast(0, wantedErr))
+			},
+			wantedErr:  async.ErrTooLessDone,
+			wantedErrs: []error{wantedErr, wantedErr, wantedErr},
+		},
+		{
+			name: "error_should_work",
+			setup: func(slow async.Task[int]) async.Waiter[int] {
+				return async.New[int](fast(0, wantedErr))
+			},
+			wantedErr:  async.ErrTooLessDone,
+			wantedErrs: []error{wantedErr},
+		},
+		{
+			name: "context_should_work",
+			setup: func(slow async.Task[int]) async.Waiter[int] {
+				return async.New[int](slow, slow, slow)
+			},
+			scripted:  scripted{cause: context.DeadlineExceeded},
+			wantedErr: context.DeadlineExceeded,
+		},
+		{
+			name: "cancel_should_work",
+			setup: func(slow async.Task[int]) async.Waiter[int] {
+				return async.New[int](slow, slow, slow)
+			},
+			scripted:  scripted{cause: context.Canceled},
+			wantedErr: context.Canceled,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			slow := asynctest.NewScript[int]()
+			a := test.setup(detached(slow.Task()))
+
+			var result int
+			var err error
+			var taskErrs []error
+
+			test.scripted.run(slow, a.Len(), func(ctx context.Context, progress async.Option) {
+				result, taskErrs, err = a.WithOptions(progress).WaitAny(ctx)
+			})
+
+			require.Equal(t, test.wantedResult, result)
+			require.Equal(t, test.wantedErr, err)
+			require.Equal(t, test.wantedErrs, taskErrs)
+		})
+	}
+}
+
+func TestWaitN(t *testing.T) {
+
+	wantedErr := errors.New("wanted")
+
+	fast := func(v int, err error) async.Task[int] {
+		return func(ctx context.Context) (int, error) {
+			return v, err
+		}
+	}
+
+	tests := []struct {
+		name         string
+		setup        func(slow async.Task[int]) async.Waiter[int]
+		scripted     scripted
+		wantedN      int
+		wantedResult []int
+		wantedErr    error
+		wantedErrs   []error
+	}{
+		{
+			name: "wait_n_should_work",
+			setup: func(slow async.Task[int]) async.Waiter[int] {
+				a := async.New[int](fast(1, nil), fast(2, nil))
+				a.Add(slow)
+				return a
+			},
+			wantedN:      2,
+			wantedResult: []int{1, 2},
+		},
+		{
+			name: "error_n_should_work",
+			setup: func(slow async.Task[int]) async.Waiter[int] {
+				return async.New[int](slow, slow, fast(0, wantedErr))
+			},
+			scripted:     scripted{fast: 1, results: []int{1, 2}},
+			wantedN:      2,
+			wantedResult: []int{1, 2},
+			wantedErrs:   []error{wantedErr},
+		},
+		{
+			name: "context_should_work",
+			setup: func(slow async.Task[int]) async.Waiter[int] {
+				return async.New[int](slow, slow, fast(3, nil))
+			},
+			scripted:     scripted{fast: 1, cause: context.DeadlineExceeded},
+			wantedN:      2,
+			wantedResult: []int{3},
+			wantedErr:    context.DeadlineExceeded,
+		},
+		{
+			name: "cancel_should_work",
+			setup: func(slow async.Task[int]) async.Waiter[int] {
+				return async.New[int](slow, slow, fast(3, nil))
+			},
+			scripted:     scripted{fast: 1, cause: context.Canceled},
+			wantedN:      2,
+			wantedResult: []int{3},
+			wantedErr:    context.Canceled,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			slow := asynctest.NewScript[int]()
+			a := test.setup(detached(slow.Task()))
+
+			var result []int
+			var err error
+			var taskErrs []error
+
+			test.scripted.run(slow, a.Len(), func(ctx context.Context, progress async.Option) {
+				result, taskErrs, err = a.WithOptions(progress).WaitN(ctx, test.wantedN)
+			})
+
+			slices.Sort(result)
+
+			require.Equal(t, test.wantedResult, result)
+			require.Equal(t, test.wantedErr, err)
+			require.Equal(t, test.wantedErrs, taskErrs)
+		})
+	}
+}
@@ -4,452 +4,293 @@ import (
 	"context"
 	"errors"
 	"slices"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/require"
 )
 
-func TestWait(t *testing.T) {
+func TestCause(t *testing.T) {
 
 	wantedErr := errors.New("wanted")
-	wantedErrs := []error{wantedErr}
+	wantedCause := errors.New("cause")
 
 	tests := []struct {
-		name         string
-		ctx          func() context.Context
-		withCancel   bool
-		setup        func() Waiter[int]
-		wantedResult []int
-		wantedErr    error
-		wantedErrs   []error
+		name        string
+		ctx         func() context.Context
+		n           int
+		setup       func(causes chan error) Waiter[int]
+		wantedErr   error
+		wantedCause error
 	}{
 		{
-			name: "wait_should_work",
+			name: "quorum_reached_should_work",
 			ctx:  context.Background,
-			setup: func() Waiter[int] {
-				a := New[int](func(ctx context.Context) (int, error) {
-					return 1, nil
-				}, func(ctx context.Context) (int, error) {
-					return 2, nil
-				})
-
-				a.Add(func(ctx context.Context) (int, error) {
-					return 3, nil
-				})
-
-				return a
-			},
-			wantedResult: []int{1, 2, 3},
-			wantedErr:    nil,
-		},
-		{
-			name: "error_should_work",
-			ctx:  context.Background,
-			setup: func() Waiter[int] {
+			n:    1,
+			setup: func(causes chan error) Waiter[int] {
 				return New[int](func(ctx context.Context) (int, error) {
 					return 1, nil
 				}, func(ctx context.Context) (int, error) {
-					return 2, nil
-				}, func(ctx context.Context) (int, error) {
-					return 0, wantedErr
+					<-ctx.Done()
+					causes <- context.Cause(ctx)
+					return 0, ctx.Err()
 				})
 			},
-			wantedResult: []int{1, 2},
-			wantedErr:    ErrTooLessDone,
-			wantedErrs:   wantedErrs,
+			wantedCause: ErrQuorumReached,
 		},
 		{
-			name: "errors_should_work",
+			name: "sibling_failed_should_work",
 			ctx:  context.Background,
-			setup: func() Waiter[int] {
+			n:    2,
+			setup: func(causes chan error) Waiter[int] {
 				return New[int](func(ctx context.Context) (int, error) {
 					return 0, wantedErr
 				}, func(ctx context.Context) (int, error) {
-					return 0, wantedErr
-				}, func(ctx context.Context) (int, error) {
-					return 0, wantedErr
+					<-ctx.Done()
+					causes <- context.Cause(ctx)
+					return 0, ctx.Err()
 				})
 			},
-			wantedErr:  ErrTooLessDone,
-			wantedErrs: []error{wantedErr, wantedErr, wantedErr},
+			wantedErr:   ErrTooLessDone,
+			wantedCause: ErrSiblingFailed,
 		},
 		{
-			name: "context_should_work",
+			name: "parent_cause_should_work",
 			ctx: func() context.Context {
-				ctx, _ := context.WithTimeout(context.Background(), 3*time.Second) //nolint
+				ctx, cancel := context.WithCancelCause(context.Background())
+				go func() {
+					time.Sleep(100 * time.Millisecond)
+					cancel(wantedCause)
+				}()
 				return ctx
 			},
-			setup: func() Waiter[int] {
+			n: 1,
+			setup: func(causes chan error) Waiter[int] {
 				return New[int](func(ctx context.Context) (int, error) {
-					time.Sleep(5 * time.Second)
-					return 1, nil
-				}, func(ctx context.Context) (int, error) {
-					time.Sleep(5 * time.Second)
-					return 2, nil
-				}, func(ctx context.Context) (int, error) {
-					return 3, nil
+					<-ctx.Done()
+					causes <- context.Cause(ctx)
+					return 0, ctx.Err()
 				})
 			},
-			wantedResult: []int{3},
-			wantedErr:    context.DeadlineExceeded,
-		},
-		{
-			name: "cancel_should_work",
-			ctx:  context.Background,
-			setup: func() Waiter[int] {
-				return New[int](func(ctx context.Context) (int, error) {
-					time.Sleep(5 * time.Second)
-					return 1, nil
-				}, func(ctx context.Context) (int, error) {
-					time.Sleep(5 * time.Second)
-					return 2, nil
-				}, func(ctx context.Context) (int, error) {
-					return 3, nil
-				})
-			},
-			withCancel:   true,
-			wantedResult: []int{3},
-			wantedErr:    context.Canceled,
+			wantedErr:   wantedCause,
+			wantedCause: wantedCause,
 		},
 	}
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			a := test.setup()
-			var result []int
-			var err error
-			var taskErrs []error
-
-			if test.withCancel {
-				ctx, cancel := context.WithCancel(context.Background())
-				go func() {
-					time.Sleep(1 * time.Second)
-					cancel()
-				}()
-				result, taskErrs, err = a.Wait(ctx)
-			} else {
-				result, taskErrs, err = a.Wait(test.ctx())
-			}
+			causes := make(chan error, 1)
+			a := test.setup(causes)
 
-			slices.Sort(result)
+			_, _, err := a.WaitN(test.ctx(), test.n)
 
-			require.Equal(t, test.wantedResult, result)
 			require.Equal(t, test.wantedErr, err)
-			require.Equal(t, test.wantedErrs, taskErrs)
-
+			require.Equal(t, test.wantedCause, <-causes)
 		})
-
 	}
 }
 
-func TestWaitAny(t *testing.T) {
-
-	wantedErr := errors.New("wanted")
+func TestDynamic(t *testing.T) {
 
-	tests := []struct {
-		name         string
-		ctx          func() context.Context
-		withCancel   bool
-		setup        func() Waiter[int]
-		wantedResult int
-		wantedErr    error
-		wantedErrs   []error
-	}{
-		{
-			name: "1st_should_work",
-			ctx:  context.Background,
-			setup: func() Waiter[int] {
-				a := New[int](func(ctx context.Context) (int, error) {
-					time.Sleep(5 * time.Second)
-					return 2, nil
-				}, func(ctx context.Context) (int, error) {
-					time.Sleep(5 * time.Second)
-					return 3, nil
-				})
+	t.Run("add_concurrently_should_work", func(t *testing.T) {
+		a := New[int]()
 
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
 				a.Add(func(ctx context.Context) (int, error) {
-					return 1, nil
+					return i, nil
 				})
+			}(i)
+		}
+
+		go func() {
+			_, _, _ = a.Wait(context.Background())
+		}()
+
+		wg.Wait()
+
+		result, taskErrs, err := a.Wait(context.Background())
+		require.NoError(t, err)
+		require.Nil(t, taskErrs)
+		require.Len(t, result, 10)
+	})
+
+	t.Run("follow_up_should_be_started_in_next_wait", func(t *testing.T) {
+		a := New[int]()
+		a.Add(func(ctx context.Context) (int, error) {
+			a.Add(func(ctx context.Context) (int, error) {
+				return 2, nil
+			})
+			return 1, nil
+		})
 
-				return a
-			},
-			wantedResult: 1,
-		},
-		{
-			name: "2nd_should_work",
-			ctx:  context.Background,
-			setup: func() Waiter[int] {
-				return New[int](func(ctx context.Context) (int, error) {
-					time.Sleep(5 * time.Second)
-					return 1, nil
-				}, func(ctx context.Context) (int, error) {
-					return 2, nil
-				}, func(ctx context.Context) (int, error) {
-					time.Sleep(5 * time.Second)
-					return 3, nil
-				})
-			},
-			wantedResult: 2,
-		},
-		{
-			name: "3rd_should_work",
-			ctx:  context.Background,
-			setup: func() Waiter[int] {
-				return New[int](func(ctx context.Context) (int, error) {
-					time.Sleep(5 * time.Second)
-					return 1, nil
-				}, func(ctx context.Context) (int, error) {
-					time.Sleep(5 * time.Second)
-					return 2, nil
-				}, func(ctx context.Context) (int, error) {
-
-					return 3, nil
-				})
-			},
-			wantedResult: 3,
-		},
-		{
-			name: "slowest_should_work",
-			ctx:  context.Background,
-			setup: func() Waiter[int] {
-				return New[int](func(ctx context.Context) (int, error) {
-					time.Sleep(3 * time.Second)
-					return 1, nil
-				}, func(ctx context.Context) (int, error) {
-					return 0, wantedErr
-				}, func(ctx context.Context) (int, error) {
-					return 0, wantedErr
-				})
-			},
-			wantedResult: 1,
-			wantedErrs:   []error{wantedErr, wantedErr},
-		},
-		{
-			name: "fastest_should_work",
-			ctx:  context.Background,
-			setup: func() Waiter[int] {
-				return New[int](func(ctx context.Context) (int, error) {
-					return 1, nil
-				}, func(ctx context.Context) (int, error) {
-					time.Sleep(2 * time.Second)
-					return 0, wantedErr
-				}, func(ctx context.Context) (int, error) {
-					time.Sleep(3 * time.Second)
-					return 0, wantedErr
-				})
-			},
-			wantedResult: 1,
-		},
-		{
-			name: "errors_should_work",
-			ctx:  context.Background,
-			setup: func() Waiter[int] {
-				return New[int](func(ctx context.Context) (int, error) {
-					return 0, wantedErr
-				}, func(ctx context.Context) (int, error) {
-					return 0, wantedErr
-				}, func(ctx context.Context) (int, error) {
-					return 0, wantedErr
-				})
-			},
-			wantedErr:  ErrTooLessDone,
-			wantedErrs: []error{wantedErr, wantedErr, wantedErr},
-		},
-		{
-			name: "error_should_work",
-			ctx:  context.Background,
-			setup: func() Waiter[int] {
-				return New[int](func(ctx context.Context) (int, error) {
-					return 0, wantedErr
-				})
-			},
-			wantedErr:  ErrTooLessDone,
-			wantedErrs: []error{wantedErr},
-		},
-		{
-			name: "context_should_work",
-			ctx: func() context.Context {
-				ctx, _ := context.WithTimeout(context.Background(), 3*time.Second) //nolint
-				return ctx
-			},
-			setup: func() Waiter[int] {
-				return New[int](func(ctx context.Context) (int, error) {
-					time.Sleep(5 * time.Second)
-					return 1, nil
-				}, func(ctx context.Context) (int, error) {
-					time.Sleep(5 * time.Second)
-					return 2, nil
-				}, func(ctx context.Context) (int, error) {
-					time.Sleep(5 * time.Second)
-					return 3, nil
-				})
-			},
-			wantedErr: context.DeadlineExceeded,
-		},
-		{
-			name: "cancel_should_work",
-			ctx:  context.Background,
-			setup: func() Waiter[int] {
-				return New[int](func(ctx context.Context) (int, error) {
-					time.Sleep(5 * time.Second)
-					return 1, nil
-				}, func(ctx context.Context) (int, error) {
-					time.Sleep(5 * time.Second)
-					return 2, nil
-				}, func(ctx context.Context) (int, error) {
-					time.Sleep(5 * time.Second)
-					return 3, nil
-				})
-			},
-			withCancel: true,
-			wantedErr:  context.Canceled,
-		},
-	}
-
-	for _, test := range tests {
-		t.Run(test.name, func(t *testing.T) {
-			a := test.setup()
-			var result int
-			var err error
-			var taskErrs []error
-
-			if test.withCancel {
-				ctx, cancel := context.WithCancel(context.Background())
-				go func() {
-					time.Sleep(1 * time.Second)
-					cancel()
-				}()
-				result, taskErrs, err = a.WaitAny(ctx)
-			} else {
-				result, taskErrs, err = a.WaitAny(test.ctx())
+		result, _, err := a.Wait(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, []int{1}, result)
+	})
+
+	t.Run("follow_up_should_be_awaited", func(t *testing.T) {
+		a := New[int]().WithOptions(WithDynamic())
+
+		var crawl func(depth int) Task[int]
+		crawl = func(depth int) Task[int] {
+			return func(ctx context.Context) (int, error) {
+				if depth < 3 {
+					a.Add(crawl(depth + 1))
+					a.Add(crawl(depth + 1))
+				}
+				return depth, nil
 			}
-
-			require.Equal(t, test.wantedResult, result)
-			require.Equal(t, test.wantedErr, err)
-			require.Equal(t, test.wantedErrs, taskErrs)
+		}
+		a.Add(crawl(0))
+
+		result, taskErrs, err := a.Wait(context.Background())
+		require.NoError(t, err)
+		require.Nil(t, taskErrs)
+
+		slices.Sort(result)
+		require.Equal(t, []int{0, 1, 1, 2, 2, 2, 2, 3, 3, 3, 3, 3, 3, 3, 3}, result)
+	})
+
+	t.Run("follow_up_should_be_counted_in_wait_n", func(t *testing.T) {
+		wantedErr := errors.New("wanted")
+		a := NewA().WithOptions(WithDynamic())
+		a.Add(func(ctx context.Context) error {
+			a.Add(func(ctx context.Context) error {
+				return nil
+			})
+			return wantedErr
 		})
 
-	}
+		taskErrs, err := a.WaitAny(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, []error{wantedErr}, taskErrs)
+	})
 }
 
-func TestWaitN(t *testing.T) {
+func TestMemoize(t *testing.T) {
 
 	wantedErr := errors.New("wanted")
 
-	tests := []struct {
-		name         string
-		ctx          func() context.Context
-		withCancel   bool
-		setup        func() Waiter[int]
-		wantedN      int
-		wantedResult []int
-		wantedErr    error
-		wantedErrs   []error
-	}{
-		{
-			name: "wait_n_should_work",
-			ctx:  context.Background,
-			setup: func() Waiter[int] {
-				a := New[int](func(ctx context.Context) (int, error) {
-					return 1, nil
-				}, func(ctx context.Context) (int, error) {
-					return 2, nil
-				})
-
-				a.Add(func(ctx context.Context) (int, error) {
-					time.Sleep(1 * time.Second)
-					return 3, nil
-				})
-
-				return a
-			},
-			wantedN:      2,
-			wantedResult: []int{1, 2},
-		},
-		{
-			name: "error_n_should_work",
-			ctx:  context.Background,
-			setup: func() Waiter[int] {
-				return New[int](func(ctx context.Context) (int, error) {
-					time.Sleep(1 * time.Second)
-					return 1, nil
-				}, func(ctx context.Context) (int, error) {
-					time.Sleep(1 * time.Second)
-					return 2, nil
-				}, func(ctx context.Context) (int, error) {
-					return 0, wantedErr
-				})
-			},
-			wantedN:      2,
-			wantedResult: []int{1, 2},
-			wantedErrs:   []error{wantedErr},
-		},
-		{
-			name: "context_should_work",
-			ctx: func() context.Context {
-				ctx, _ := context.WithTimeout(context.Background(), 3*time.Second) //nolint
-				return ctx
-			},
-			setup: func() Waiter[int] {
-				return New[int](func(ctx context.Context) (int, error) {
-					time.Sleep(5 * time.Second)
-					return 1, nil
-				}, func(ctx context.Context) (int, error) {
-					time.Sleep(5 * time.Second)
-					return 2, nil
-				}, func(ctx context.Context) (int, error) {
-					return 3, nil
-				})
-			},
-			wantedResult: []int{3},
-			wantedErr:    context.DeadlineExceeded,
-		},
-		{
-			name: "cancel_should_work",
-			ctx:  context.Background,
-			setup: func() Waiter[int] {
-				return New[int](func(ctx context.Context) (int, error) {
-					time.Sleep(5 * time.Second)
-					return 1, nil
-				}, func(ctx context.Context) (int, error) {
-					time.Sleep(5 * time.Second)
-					return 2, nil
-				}, func(ctx context.Context) (int, error) {
-					return 3, nil
-				})
-			},
-			withCancel:   true,
-			wantedResult: []int{3},
-			wantedErr:    context.Canceled,
-		},
-	}
-
-	for _, test := range tests {
-		t.Run(test.name, func(t *testing.T) {
-			a := test.setup()
-			var result []int
-			var err error
-			var taskErrs []error
-
-			if test.withCancel {
-				ctx, cancel := context.WithCancel(context.Background())
-				go func() {
-					time.Sleep(1 * time.Second)
-					cancel()
-				}()
-				result, taskErrs, err = a.WaitN(ctx, test.wantedN)
-			} else {
-				result, taskErrs, err = a.WaitN(test.ctx(), test.wantedN)
+	t.Run("memoize_should_work", func(t *testing.T) {
+		var started atomic.Int32
+
+		a := New[int](func(ctx context.Context) (int, error) {
+			started.Add(1)
+			return 1, nil
+		}, func(ctx context.Context) (int, error) {
+			started.Add(1)
+			return 0, wantedErr
+		}).WithOptions(WithMemoize())
+
+		for i := 0; i < 3; i++ {
+			result, taskErrs, err := a.Wait(context.Background())
+			require.Equal(t, ErrTooLessDone, err)
+			require.Equal(t, []error{wantedErr}, taskErrs)
+			require.Equal(t, []int{1}, result)
+		}
+
+		result, _, err := a.WaitAny(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, 1, result)
+		require.Equal(t, int32(2), started.Load())
+
+		a.Reset()
+		_, _, _ = a.Wait(context.Background())
+		require.Equal(t, int32(4), started.Load())
+	})
+
+	t.Run("canceled_should_be_started_again", func(t *testing.T) {
+		var started atomic.Int32
+		canceled := make(chan struct{})
+
+		a := New[int](func(ctx context.Context) (int, error) {
+			return 1, nil
+		}, func(ctx context.Context) (int, error) {
+			started.Add(1)
+			if info, _ := InfoFrom(ctx); info.Attempt == 1 {
+				<-ctx.Done()
+				close(canceled)
+				return 0, ctx.Err()
 			}
+			return 2, nil
+		}).WithOptions(WithMemoize())
+
+		result, _, err := a.WaitAny(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, 1, result)
+
+		// the first attempt is completed before it is started again
+		<-canceled
+
+		items, taskErrs, err := a.Wait(context.Background())
+		require.NoError(t, err)
+		require.Nil(t, taskErrs)
+		slices.Sort(items)
+		require.Equal(t, []int{1, 2}, items)
+		require.Equal(t, int32(2), started.Load())
+	})
+
+	t.Run("without_memoize_should_start_again", func(t *testing.T) {
+		var started atomic.Int32
+
+		a := NewA(func(ctx context.Context) error {
+			started.Add(1)
+			return nil
+		})
 
-			slices.Sort(result)
+		_, _ = a.Wait(context.Background())
+		_, _ = a.Wait(context.Background())
+		require.Equal(t, int32(2), started.Load())
+	})
+}
 
-			require.Equal(t, test.wantedResult, result)
-			require.Equal(t, test.wantedErr, err)
-			require.Equal(t, test.wantedErrs, taskErrs)
+func TestRemove(t *testing.T) {
+	a := New[int](func(ctx context.Context) (int, error) {
+		return 1, nil
+	}, func(ctx context.Context) (int, error) {
+		return 2, nil
+	}, func(ctx context.Context) (int, error) {
+		return 3, nil
+	})
+
+	require.Equal(t, 3, a.Len())
+	require.True(t, a.Remove(1))
+	require.False(t, a.Remove(2))
+	require.False(t, a.Remove(-1))
+	require.Equal(t, 2, a.Len())
+
+	result, _, err := a.Wait(context.Background())
+	require.NoError(t, err)
+	slices.Sort(result)
+	require.Equal(t, []int{1, 3}, result)
+
+	aa := NewA(func(ctx context.Context) error {
+		return nil
+	})
+	require.Equal(t, 1, aa.Len())
+	require.True(t, aa.Remove(0))
+	require.Equal(t, 0, aa.Len())
+}
 
-		})
+func TestPanic(t *testing.T) {
+	task := func(ctx context.Context) (int, error) {
+		panic("boom")
+	}
 
+	for _, opts := range [][]Option{nil, {WithConcurrency(NewFixedLimiter(1))}} {
+		result, taskErrs, err := New[int](task, func(ctx context.Context) (int, error) {
+			return 1, nil
+		}).WithOptions(opts...).Wait(context.Background())
+		require.ErrorIs(t, err, ErrTooLessDone)
+		require.Equal(t, []int{1}, result)
+		require.Len(t, taskErrs, 1)
+		require.ErrorIs(t, taskErrs[0], ErrPanic)
+		require.Contains(t, taskErrs[0].Error(), "boom")
 	}
 }
@@ -0,0 +1,68 @@
+package async
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"runtime/pprof"
+	"strings"
+	"time"
+)
+
+// taskLabel the pprof label that identifies the goroutines of a task/action, whose value is WaiterID/Index/Attempt
+const taskLabel = "async.task"
+
+// watch call fn with the pprof label of info, and report it to o.OnHung with its stack once it is not completed in
+// threshold by the clock of ctx
+func watch(ctx context.Context, info TaskInfo, threshold time.Duration, o *Observer, fn func(ctx context.Context)) {
+	id := fmt.Sprintf("%d/%d/%d", info.WaiterID, info.Index, info.Attempt)
+
+	clock := ClockFrom(ctx)
+	started := clock.Now()
+	timer := clock.NewTimer(threshold)
+	done := make(chan struct{})
+
+	go func() {
+		select {
+		case <-timer.C():
+			stack := stackOf(id)
+
+			// it is completed while the stack is captured
+			select {
+			case <-done:
+				return
+			default:
+			}
+
+			o.hung(info, clock.Now().Sub(started), stack)
+		case <-done:
+			timer.Stop()
+		}
+	}()
+
+	pprof.Do(ctx, pprof.Labels(taskLabel, id), fn)
+	close(done)
+}
+
+// stackOf get the stacks of the goroutines that are labeled with id from the goroutine profile, including the ones
+// that are started by the task/action
+func stackOf(id string) []byte {
+	var buf bytes.Buffer
+	if err := pprof.Lookup("goroutine").WriteTo(&buf, 1); err != nil {
+		return nil
+	}
+
+	needle := fmt.Sprintf("%q:%q", taskLabel, id)
+
+	var stacks []string
+	for _, record := range strings.Split(buf.String(), "\n\n") {
+		if strings.Contains(record, needle) {
+			stacks = append(stacks, strings.TrimSpace(record))
+		}
+	}
+
+	if len(stacks) == 0 {
+		return nil
+	}
+	return []byte(strings.Join(stacks, "\n\n"))
+}
@@ -0,0 +1,111 @@
+package async
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type hung struct {
+	info    TaskInfo
+	elapsed time.Duration
+	stack   []byte
+}
+
+//go:noinline
+func waitForRelease(release chan struct{}) {
+	<-release
+}
+
+func TestWatchdog(t *testing.T) {
+
+	t.Run("hung_task_should_be_reported", func(t *testing.T) {
+		reports := make(chan hung, 2)
+		release := make(chan struct{})
+
+		w := New[int]().WithOptions(WithWatchdog(20*time.Millisecond), WithObserver(Observer{
+			OnHung: func(info TaskInfo, elapsed time.Duration, stack []byte) {
+				reports <- hung{info: info, elapsed: elapsed, stack: stack}
+			},
+		}))
+		w.AddNamed("fast", func(ctx context.Context) (int, error) {
+			return 1, nil
+		})
+		w.AddNamed("stuck", func(ctx context.Context) (int, error) {
+			// it ignores ctx
+			waitForRelease(release)
+			return 2, nil
+		})
+
+		done := make(chan error, 1)
+		go func() {
+			_, _, err := w.Wait(context.Background())
+			done <- err
+		}()
+
+		r := <-reports
+		require.Equal(t, "stuck", r.info.Name)
+		require.Equal(t, 1, r.info.Index)
+		require.GreaterOrEqual(t, r.elapsed, 20*time.Millisecond)
+		require.Contains(t, string(r.stack), "waitForRelease")
+		require.Contains(t, string(r.stack), `"async.task":"`)
+
+		close(release)
+		require.NoError(t, <-done)
+		require.Empty(t, reports)
+	})
+
+	t.Run("completed_task_should_not_be_reported", func(t *testing.T) {
+		var reports atomic.Int32
+
+		_, err := NewA(func(ctx context.Context) error {
+			return nil
+		}).WithOptions(WithWatchdog(10*time.Millisecond), WithObserver(Observer{
+			OnHung: func(info TaskInfo, elapsed time.Duration, stack []byte) {
+				reports.Add(1)
+			},
+		})).Wait(context.Background())
+		require.NoError(t, err)
+
+		time.Sleep(20 * time.Millisecond)
+		require.Zero(t, reports.Load())
+	})
+
+	t.Run("labels_should_be_restored_on_workers", func(t *testing.T) {
+		e := NewWorkerExecutor(1, 4)
+		defer e.Close()
+
+		reports := make(chan hung, 1)
+		release := make(chan struct{})
+
+		a := NewA().WithOptions(WithExecutor(e), WithWatchdog(20*time.Millisecond), WithObserver(Observer{
+			OnHung: func(info TaskInfo, elapsed time.Duration, stack []byte) {
+				reports <- hung{info: info, stack: stack}
+			},
+		}))
+		a.Add(func(ctx context.Context) error {
+			return nil
+		})
+		a.Add(func(ctx context.Context) error {
+			waitForRelease(release)
+			return nil
+		})
+
+		done := make(chan error, 1)
+		go func() {
+			_, err := a.Wait(context.Background())
+			done <- err
+		}()
+
+		r := <-reports
+		require.Equal(t, 1, r.info.Index)
+		require.Contains(t, string(r.stack), "waitForRelease")
+		require.NotContains(t, string(r.stack), "/0/1\"")
+
+		close(release)
+		require.NoError(t, <-done)
+	})
+}
@@ -0,0 +1,384 @@
+package async
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrUnknownTask is returned when a workflow uses a task/action that is not registered
+	ErrUnknownTask = errors.New("async: unknown task")
+	// ErrInvalidSpec is returned when a workflow has invalid values
+	ErrInvalidSpec = errors.New("async: invalid workflow spec")
+)
+
+// WorkflowMode how the nodes of a workflow are run
+type WorkflowMode string
+
+const (
+	// ModeDAG runs nodes by their dependencies, see DAG. It is the default mode.
+	ModeDAG WorkflowMode = "dag"
+	// ModeAll waits for all nodes to completed, see Waiter.Wait
+	ModeAll WorkflowMode = "all"
+	// ModeAny waits for any node to completed, see Waiter.WaitAny
+	ModeAny WorkflowMode = "any"
+	// ModeN waits for Quorum nodes to completed, see Waiter.WaitN
+	ModeN WorkflowMode = "n"
+)
+
+// Duration a time.Duration that is written as string in JSON/YAML, e.g. "1.5s"
+type Duration time.Duration
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+
+	*d = Duration(v)
+	return nil
+}
+
+// WorkflowSpec the declarative definition of a workflow. It can be decoded from JSON by ParseWorkflow, or from YAML by
+// any YAML package that supports yaml tags and encoding.TextUnmarshaler.
+type WorkflowSpec struct {
+	Mode WorkflowMode `json:"mode,omitempty" yaml:"mode,omitempty"`
+	// Quorum is the number of nodes to wait for in ModeN
+	Quorum int `json:"quorum,omitempty" yaml:"quorum,omitempty"`
+	// Timeout cancels the whole workflow if it is not zero
+	Timeout Duration   `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+	Nodes   []NodeSpec `json:"nodes" yaml:"nodes"`
+}
+
+// NodeSpec the declarative definition of a node in workflow
+type NodeSpec struct {
+	Name string `json:"name" yaml:"name"`
+	// Task is the name of the task/action that is registered in Registry
+	Task string `json:"task" yaml:"task"`
+	// Deps are the names of upstream nodes, they are allowed in ModeDAG only
+	Deps []string `json:"deps,omitempty" yaml:"deps,omitempty"`
+	// Timeout cancels the task if it is not zero, see Timeout
+	Timeout Duration `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+	// Retries is the number of retries after the first attempt, see Retry
+	Retries int      `json:"retries,omitempty" yaml:"retries,omitempty"`
+	Backoff Duration `json:"backoff,omitempty" yaml:"backoff,omitempty"`
+}
+
+// SpecError the validation error of a WorkflowSpec, which points at the offending node
+type SpecError struct {
+	// Index is the position of the node in WorkflowSpec.Nodes, it is -1 if the error is not about a node
+	Index int
+	Node  string
+	Field string
+	Err   error
+}
+
+func (e *SpecError) Error() string {
+	if e.Index < 0 {
+		return fmt.Sprintf("%s: %s", e.Field, e.Err)
+	}
+
+	return fmt.Sprintf("nodes[%d] %q: %s: %s", e.Index, e.Node, e.Field, e.Err)
+}
+
+func (e *SpecError) Unwrap() error {
+	return e.Err
+}
+
+// ParseWorkflow decode a WorkflowSpec from JSON. Unknown fields are rejected.
+func ParseWorkflow(data []byte) (WorkflowSpec, error) {
+	var spec WorkflowSpec
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&spec); err != nil {
+		return spec, fmt.Errorf("%w: %w", ErrInvalidSpec, err)
+	}
+
+	return spec, nil
+}
+
+// Registry the named tasks/actions that can be used in workflows
+type Registry[T any] struct {
+	mu    sync.RWMutex
+	tasks map[string]Task[T]
+}
+
+// NewRegistry create a Registry of tasks
+func NewRegistry[T any]() *Registry[T] {
+	return &Registry[T]{
+		tasks: make(map[string]Task[T]),
+	}
+}
+
+// Register register a task with name, it replaces the task that is registered with the same name
+func (r *Registry[T]) Register(name string, task Task[T]) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.tasks[name] = task
+}
+
+// RegisterAction register an action with name, its result is the zero value of T
+func (r *Registry[T]) RegisterAction(name string, action Action) {
+	r.Register(name, func(ctx context.Context) (T, error) {
+		var t T
+		return t, action(ctx)
+	})
+}
+
+// Load decode a workflow from JSON, and build it with registered tasks/actions
+func (r *Registry[T]) Load(data []byte) (*Workflow[T], error) {
+	spec, err := ParseWorkflow(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.Build(spec)
+}
+
+// Build validate spec, and build a workflow with registered tasks/actions. All validation errors are joined as
+// SpecError, see errors.As.
+func (r *Registry[T]) Build(spec WorkflowSpec) (*Workflow[T], error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if spec.Mode == "" {
+		spec.Mode = ModeDAG
+	}
+
+	var err error
+	invalid := func(i int, field string, e error) {
+		name := ""
+		if i >= 0 {
+			name = spec.Nodes[i].Name
+		}
+		err = errors.Join(err, &SpecError{Index: i, Node: name, Field: field, Err: e})
+	}
+
+	switch spec.Mode {
+	case ModeDAG, ModeAll, ModeAny:
+		if spec.Quorum != 0 {
+			invalid(-1, "quorum", fmt.Errorf("%w: it is allowed in mode %q only", ErrInvalidSpec, ModeN))
+		}
+	case ModeN:
+		if spec.Quorum < 1 || spec.Quorum > len(spec.Nodes) {
+			invalid(-1, "quorum", fmt.Errorf("%w: %d is out of [1, %d]", ErrInvalidSpec, spec.Quorum, len(spec.Nodes)))
+		}
+	default:
+		invalid(-1, "mode", fmt.Errorf("%w: %q", ErrInvalidSpec, spec.Mode))
+	}
+
+	if spec.Timeout < 0 {
+		invalid(-1, "timeout", fmt.Errorf("%w: it can't be negative", ErrInvalidSpec))
+	}
+
+	w := &Workflow[T]{
+		spec: spec,
+	}
+
+	if spec.Mode == ModeDAG {
+		w.dag = NewDAG[T]()
+	}
+
+	names := make(map[string]bool)
+	for i, n := range spec.Nodes {
+		if n.Name == "" {
+			invalid(i, "name", fmt.Errorf("%w: it is required", ErrInvalidSpec))
+		} else if names[n.Name] {
+			invalid(i, "name", fmt.Errorf("%w: %q", ErrDuplicateNode, n.Name))
+		}
+		names[n.Name] = true
+
+		task, ok := r.tasks[n.Task]
+		if !ok {
+			invalid(i, "task", fmt.Errorf("%w: %q", ErrUnknownTask, n.Task))
+		}
+
+		if n.Timeout < 0 {
+			invalid(i, "timeout", fmt.Errorf("%w: it can't be negative", ErrInvalidSpec))
+		}
+
+		if n.Retries < 0 {
+			invalid(i, "retries", fmt.Errorf("%w: it can't be negative", ErrInvalidSpec))
+		}
+
+		if n.Backoff < 0 {
+			invalid(i, "backoff", fmt.Errorf("%w: it can't be negative", ErrInvalidSpec))
+		}
+
+		if len(n.Deps) > 0 && spec.Mode != ModeDAG {
+			invalid(i, "deps", fmt.Errorf("%w: it is allowed in mode %q only", ErrInvalidSpec, ModeDAG))
+		}
+
+		for _, dep := range n.Deps {
+			if !containsNode(spec.Nodes, dep) {
+				invalid(i, "deps", fmt.Errorf("%w: %q", ErrUnknownNode, dep))
+			}
+		}
+
+		if !ok {
+			continue
+		}
+
+		if n.Timeout > 0 {
+			task = Timeout(task, time.Duration(n.Timeout))
+		}
+
+		if n.Retries > 0 {
+			task = Retry(task, n.Retries+1, time.Duration(n.Backoff))
+		}
+
+		if w.dag != nil {
+			w.dag.Add(n.Name, task, n.Deps...)
+		} else {
+			w.tasks = append(w.tasks, task)
+		}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if w.dag != nil {
+		if err := w.dag.Build(); err != nil {
+			var ce *cycleError
+			if errors.As(err, &ce) {
+				for i, n := range spec.Nodes {
+					if n.Name == ce.path[0] {
+						return nil, &SpecError{Index: i, Node: n.Name, Field: "deps", Err: err}
+					}
+				}
+			}
+			return nil, err
+		}
+	}
+
+	return w, nil
+}
+
+func containsNode(nodes []NodeSpec, name string) bool {
+	for _, n := range nodes {
+		if n.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Workflow the nodes that are built from WorkflowSpec
+type Workflow[T any] struct {
+	spec WorkflowSpec
+	dag  *DAG[T]
+	// tasks are the tasks of nodes in other modes than ModeDAG
+	tasks []Task[T]
+}
+
+// Spec get the WorkflowSpec that the workflow is built from
+func (w *Workflow[T]) Spec() WorkflowSpec {
+	return w.spec
+}
+
+// DAG get the DAG of workflow in ModeDAG, it is nil in other modes
+func (w *Workflow[T]) DAG() *DAG[T] {
+	return w.dag
+}
+
+// Run run the workflow by its mode, and report the outcome of each node. The error is the same as DAG.Run in ModeDAG,
+// and the same as Waiter in other modes. In ModeAny and ModeN, the nodes that are canceled once enough nodes are
+// completed are reported as NodeCanceled with the cause, e.g. ErrQuorumReached. The report is measured by the Clock
+// of ctx.
+func (w *Workflow[T]) Run(ctx context.Context) (*Report[T], error) {
+	if w.spec.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = withTimeout(ctx, time.Duration(w.spec.Timeout))
+		defer cancel()
+	}
+
+	if w.dag != nil {
+		return w.dag.Run(ctx)
+	}
+
+	clock := ClockFrom(ctx)
+	started := clock.Now()
+
+	var mu sync.Mutex
+	// runCtx is the context of tasks in the run, its cause is reported for the nodes that are not completed
+	var runCtx context.Context
+	report := &Report[T]{
+		Nodes: make([]NodeReport[T], len(w.spec.Nodes)),
+	}
+	for i, n := range w.spec.Nodes {
+		report.Nodes[i] = NodeReport[T]{Name: n.Name}
+	}
+
+	// tasks are wrapped in each run, so they report their outcome
+	g := newWaiter[T]()
+	for i, task := range w.tasks {
+		i, task := i, task
+		g.AddNamed(w.spec.Nodes[i].Name, func(ctx context.Context) (T, error) {
+			mu.Lock()
+			report.Nodes[i].Started = clock.Now()
+			if runCtx == nil {
+				runCtx = ctx
+			}
+			mu.Unlock()
+
+			t, err := task(ctx)
+
+			mu.Lock()
+			defer mu.Unlock()
+			nr := &report.Nodes[i]
+			nr.Elapsed = clock.Now().Sub(nr.Started)
+			nr.Data = t
+			nr.Error = err
+			nr.Status = NodeSucceeded
+			if err != nil {
+				nr.Status = NodeFailed
+			}
+			return t, err
+		})
+	}
+
+	var err error
+	switch w.spec.Mode {
+	case ModeAll:
+		_, _, err = g.Wait(ctx)
+	case ModeAny:
+		_, _, err = g.WaitAny(ctx)
+	case ModeN:
+		_, _, err = g.WaitN(ctx, w.spec.Quorum)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	result := &Report[T]{
+		Nodes:   make([]NodeReport[T], len(report.Nodes)),
+		Elapsed: clock.Now().Sub(started),
+	}
+
+	cause := context.Cause(ctx)
+	if runCtx != nil {
+		cause = context.Cause(runCtx)
+	}
+
+	copy(result.Nodes, report.Nodes)
+	for i := range result.Nodes {
+		if result.Nodes[i].Status == NodePending {
+			result.Nodes[i].Status = NodeCanceled
+			result.Nodes[i].Error = cause
+		}
+	}
+
+	return result, err
+}
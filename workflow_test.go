@@ -0,0 +1,177 @@
+package async
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkflow(t *testing.T) {
+
+	wantedErr := errors.New("wanted")
+
+	r := NewRegistry[int]()
+	r.Register("one", func(ctx context.Context) (int, error) {
+		return 1, nil
+	})
+	r.Register("sum", func(ctx context.Context) (int, error) {
+		a, _ := Upstream[int](ctx, "a")
+		b, _ := Upstream[int](ctx, "b")
+		return a + b, nil
+	})
+	r.Register("flaky", func(ctx context.Context) (int, error) {
+		info, _ := InfoFrom(ctx)
+		if info.Attempt < 3 {
+			return 0, wantedErr
+		}
+		return info.Attempt, nil
+	})
+	r.Register("slow", func(ctx context.Context) (int, error) {
+		<-ctx.Done()
+		return 0, ctx.Err()
+	})
+	r.RegisterAction("noop", func(ctx context.Context) error {
+		return nil
+	})
+
+	t.Run("dag_should_work", func(t *testing.T) {
+		w, err := r.Load([]byte(`{
+			"nodes": [
+				{"name": "a", "task": "one"},
+				{"name": "b", "task": "flaky", "retries": 2, "backoff": "1ms"},
+				{"name": "c", "task": "sum", "deps": ["a", "b"], "timeout": "1s"},
+				{"name": "d", "task": "noop", "deps": ["c"]}
+			]
+		}`))
+		require.NoError(t, err)
+		require.Equal(t, ModeDAG, w.Spec().Mode)
+		require.NotNil(t, w.DAG())
+
+		report, err := w.Run(context.Background())
+		require.NoError(t, err)
+
+		c, _ := report.Node("c")
+		require.Equal(t, 4, c.Data)
+	})
+
+	t.Run("timeout_should_work", func(t *testing.T) {
+		w, err := r.Load([]byte(`{
+			"mode": "all",
+			"nodes": [
+				{"name": "a", "task": "one"},
+				{"name": "b", "task": "slow", "timeout": "10ms"}
+			]
+		}`))
+		require.NoError(t, err)
+
+		report, err := w.Run(context.Background())
+		require.Equal(t, ErrTooLessDone, err)
+
+		b, _ := report.Node("b")
+		require.Equal(t, NodeFailed, b.Status)
+		require.Equal(t, context.DeadlineExceeded, b.Error)
+	})
+
+	t.Run("quorum_should_work", func(t *testing.T) {
+		w, err := r.Load([]byte(`{
+			"mode": "n",
+			"quorum": 2,
+			"timeout": "1s",
+			"nodes": [
+				{"name": "a", "task": "one"},
+				{"name": "b", "task": "one"},
+				{"name": "c", "task": "slow"}
+			]
+		}`))
+		require.NoError(t, err)
+
+		report, err := w.Run(context.Background())
+		require.NoError(t, err)
+		require.Less(t, report.Elapsed, time.Second)
+
+		a, _ := report.Node("a")
+		require.Equal(t, NodeSucceeded, a.Status)
+		c, _ := report.Node("c")
+		require.Equal(t, NodeCanceled, c.Status)
+	})
+
+	t.Run("any_should_work", func(t *testing.T) {
+		w, err := r.Load([]byte(`{"mode": "any", "nodes": [{"name": "a", "task": "one"}, {"name": "b", "task": "slow"}]}`))
+		require.NoError(t, err)
+
+		_, err = w.Run(context.Background())
+		require.NoError(t, err)
+	})
+
+	t.Run("canceled_should_be_reported_with_cause", func(t *testing.T) {
+		release := make(chan struct{})
+		defer close(release)
+		r.Register("stuck", func(ctx context.Context) (int, error) {
+			<-release
+			return 0, nil
+		})
+
+		w, err := r.Load([]byte(`{"mode": "any", "nodes": [{"name": "a", "task": "one"}, {"name": "b", "task": "stuck"}]}`))
+		require.NoError(t, err)
+
+		clock := &fixedClock{now: time.Date(2024, 3, 18, 0, 0, 0, 0, time.UTC)}
+		report, err := w.Run(ContextWithClock(context.Background(), clock))
+		require.NoError(t, err)
+		require.Zero(t, report.Elapsed)
+
+		a, _ := report.Node("a")
+		require.Equal(t, NodeSucceeded, a.Status)
+		require.Equal(t, clock.now, a.Started)
+		require.Zero(t, a.Elapsed)
+
+		b, _ := report.Node("b")
+		require.Equal(t, NodeCanceled, b.Status)
+		require.ErrorIs(t, b.Error, ErrQuorumReached)
+	})
+
+	t.Run("validation_should_work", func(t *testing.T) {
+		_, err := r.Load([]byte(`{
+			"mode": "all",
+			"nodes": [
+				{"name": "a", "task": "one"},
+				{"name": "b", "task": "missing"},
+				{"name": "c", "task": "one", "deps": ["a"], "retries": -1}
+			]
+		}`))
+		require.ErrorIs(t, err, ErrUnknownTask)
+		require.ErrorIs(t, err, ErrInvalidSpec)
+
+		var se *SpecError
+		require.ErrorAs(t, err, &se)
+		require.Equal(t, 1, se.Index)
+		require.Equal(t, "b", se.Node)
+		require.Equal(t, "task", se.Field)
+		require.ErrorContains(t, err, `nodes[2] "c": retries:`)
+		require.ErrorContains(t, err, `nodes[2] "c": deps:`)
+
+		_, err = r.Load([]byte(`{"mode": "n", "quorum": 3, "nodes": [{"name": "a", "task": "one"}]}`))
+		require.ErrorIs(t, err, ErrInvalidSpec)
+		require.ErrorContains(t, err, "quorum: ")
+
+		_, err = r.Load([]byte(`{"nodes": [{"name": "a", "task": "one", "deps": ["x"]}, {"name": "a", "task": "one"}]}`))
+		require.ErrorIs(t, err, ErrUnknownNode)
+		require.ErrorIs(t, err, ErrDuplicateNode)
+
+		_, err = r.Load([]byte(`{"nodes": [{"name": "a", "task": "one", "deps": ["b"]}, {"name": "b", "task": "one", "deps": ["a"]}]}`))
+		require.ErrorIs(t, err, ErrCycle)
+		require.ErrorAs(t, err, &se)
+		require.Equal(t, 0, se.Index)
+		require.Equal(t, "a", se.Node)
+		require.Equal(t, "deps", se.Field)
+		require.ErrorContains(t, err, `nodes[0] "a": deps: async: dependency cycle: a -> b -> a`)
+
+		_, err = r.Load([]byte(`{"nodes": [{"name": "a", "task": "one", "retry": 1}]}`))
+		require.ErrorIs(t, err, ErrInvalidSpec)
+
+		_, err = r.Load([]byte(`{"nodes": [{"name": "a", "task": "one", "timeout": "1 minute"}]}`))
+		require.ErrorIs(t, err, ErrInvalidSpec)
+	})
+}